@@ -1,48 +1,186 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/AnatolyRugalev/chaingen/pkg/chaingen"
 )
 
-var (
-	flags   = flag.NewFlagSet("chaingen", flag.ExitOnError)
-	options = &chaingen.Options{}
-)
+// command holds command line flags
+type command struct {
+	flags      *flag.FlagSet
+	options    chaingen.Options
+	configPath string
+	watch      bool
+	interval   time.Duration
+}
 
-func init() {
+func newCommand() *command {
+	cmd := &command{
+		flags: flag.NewFlagSet("chaingen", flag.ExitOnError),
+	}
+	flags, options := cmd.flags, &cmd.options
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: chaingen [flags] [packages]\n")
+		flags.PrintDefaults()
+	}
 	wd, _ := os.Getwd()
 	flags.StringVar(&options.Src, "src", wd, "Builder package directory")
-	flags.StringVar(&options.TypeName, "type", "", "Builder type names, separated by comma")
+	flags.StringVar(&options.TypeName, "type", "", "Builder type names, separated by comma. If not set, builders are discovered")
 	flags.BoolVar(&options.Recursive, "recursive", false, "Whether to recuresively generate code for nested builders")
 	flags.StringVar(&options.FileSuffix, "file-suffix", ".chaingen.go", "Generated file suffix, including '.go'")
-	flags.BoolVar(&options.ErrOnConflict, "err-on-conflict", true, "Whether to return error if method naming conflict is encountered")
+	flags.BoolVar(&options.ErrOnConflict, "err-on-conflict", true, "Whether to return error if method naming conflict is encountered. Ignored if -conflict is set")
+	flags.Func("conflict", "Method naming conflict strategy: error, parent-wins, first-child-wins or fan-out", func(s string) error {
+		strategy, err := chaingen.ParseConflictStrategy(s)
+		options.Conflict = strategy
+		return err
+	})
+	flags.BoolVar(&options.ErrOnUnrenderable, "err-on-unrenderable", true, "Whether to return error if method refers types that are not accessible from the builder package")
+	flags.Var(negatedBool{&options.Lenient}, "strict", "Whether to return error if annotation contains unknown modifiers")
 	flags.StringVar(&options.StructTag, "struct-tag", "chaingen", "Sets struct tag name to use")
 	flags.StringVar(&options.BuildTag, "build-tag", "chaingen", "Sets go build tag name that is used to ignore generated files while analyzing code")
+	flags.BoolVar(&options.Check, "check", false, "Whether to only check that generated files are up to date without writing them")
+	flags.BoolVar(&options.Diff, "diff", false, "Whether to print unified diff of generated files instead of writing them")
+	flags.BoolVar(&options.API, "api", false, "Whether to generate interfaces describing chained API of every builder")
+	flags.BoolVar(&options.Cache, "cache", false, "Whether to skip generation if source files, options and generated files are unchanged since the last run")
+	flags.StringVar(&cmd.configPath, "config", "", "Config file path. By default "+chaingen.ConfigFileName+" is looked up from -src directory up to the module root")
+	flags.BoolVar(&options.RemoveOrphans, "remove-orphans", true, "Whether to remove previously generated files that are no longer produced")
+	flags.BoolVar(&cmd.watch, "watch", false, "Whether to keep running and regenerate code when source files of builders change")
+	flags.DurationVar(&cmd.interval, "watch-interval", 100*time.Millisecond, "Source files polling interval of watch mode")
+	return cmd
 }
 
 func main() {
-	err := flags.Parse(os.Args[1:])
+	cmd := newCommand()
+	err := cmd.flags.Parse(os.Args[1:])
 	if err != nil {
 		log.Println(err.Error())
-		flags.Usage()
+		cmd.flags.Usage()
 		os.Exit(2)
 	}
-	options.Src, err = filepath.Abs(options.Src)
+	generator, err := cmd.generator()
 	if err != nil {
 		fmt.Println(err.Error())
-		flags.Usage()
 		os.Exit(2)
 	}
-	err = chaingen.New(*options).Generate()
+	if cmd.watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err = generator.Watch(ctx, cmd.interval)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	err = generator.Generate()
 	if err != nil {
 		fmt.Println(err.Error())
-		flags.Usage()
+		cmd.flags.Usage()
 		os.Exit(1)
 	}
 }
+
+// generator returns generator configured by parsed flags and the config file.
+// The config is applied to every loaded package, flags take precedence over it
+func (cmd *command) generator() (chaingen.Chaingen, error) {
+	var err error
+	cmd.options.Src, err = filepath.Abs(cmd.options.Src)
+	if err != nil {
+		return chaingen.Chaingen{}, err
+	}
+	cmd.options.Patterns = cmd.flags.Args()
+	if cmd.configPath == "" {
+		cmd.configPath, err = chaingen.FindConfig(cmd.options.Src)
+		if err != nil {
+			return chaingen.Chaingen{}, err
+		}
+		if cmd.configPath == "" {
+			return chaingen.New(cmd.options), nil
+		}
+	}
+	config, err := chaingen.LoadConfig(cmd.configPath)
+	if err != nil {
+		return chaingen.Chaingen{}, err
+	}
+	config.Flags = cmd.setFlags()
+	generator, err := chaingen.NewWithConfig(cmd.options, config)
+	if err != nil {
+		return chaingen.Chaingen{}, fmt.Errorf("error applying config %s: %w", cmd.configPath, err)
+	}
+	return generator, nil
+}
+
+// setFlags returns options that are set explicitly by command line flags
+func (cmd *command) setFlags() chaingen.ConfigOptions {
+	set := chaingen.ConfigOptions{}
+	options := cmd.options
+	cmd.flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "type":
+			set.TypeName = &options.TypeName
+		case "recursive":
+			set.Recursive = &options.Recursive
+		case "file-suffix":
+			set.FileSuffix = &options.FileSuffix
+		case "err-on-conflict":
+			set.ErrOnConflict = &options.ErrOnConflict
+		case "conflict":
+			conflict := string(options.Conflict)
+			set.Conflict = &conflict
+		case "struct-tag":
+			set.StructTag = &options.StructTag
+		case "build-tag":
+			set.BuildTag = &options.BuildTag
+		case "check":
+			set.Check = &options.Check
+		case "diff":
+			set.Diff = &options.Diff
+		case "remove-orphans":
+			set.RemoveOrphans = &options.RemoveOrphans
+		case "err-on-unrenderable":
+			set.ErrOnUnrenderable = &options.ErrOnUnrenderable
+		case "strict":
+			strict := !options.Lenient
+			set.Strict = &strict
+		case "api":
+			set.API = &options.API
+		case "cache":
+			set.Cache = &options.Cache
+		}
+	})
+	return set
+}
+
+// negatedBool is a boolean flag that sets the negated value
+type negatedBool struct {
+	value *bool
+}
+
+func (b negatedBool) String() string {
+	if b.value == nil {
+		return "false"
+	}
+	return strconv.FormatBool(!*b.value)
+}
+
+func (b negatedBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.value = !v
+	return nil
+}
+
+func (b negatedBool) IsBoolFlag() bool {
+	return true
+}
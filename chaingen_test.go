@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.22\n",
+		"chaingen.json": `{
+  "recursive": true,
+  "strict": false,
+  "cache": false,
+  "packages": {
+    "sub": {"file-suffix": ".sub.go", "recursive": false}
+  }
+}`,
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub := filepath.Join(dir, "sub")
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		recursive bool
+		lenient   bool
+		cache     bool
+		suffix    string
+		// Options of the sub package
+		subRecursive bool
+		subSuffix    string
+	}{
+		{
+			name:         "config",
+			recursive:    true,
+			lenient:      true,
+			suffix:       ".chaingen.go",
+			subSuffix:    ".sub.go",
+			subRecursive: false,
+		},
+		{
+			name:         "flags",
+			args:         []string{"-recursive=false", "-strict", "-cache", "-file-suffix", ".flag.go"},
+			recursive:    false,
+			lenient:      false,
+			cache:        true,
+			suffix:       ".flag.go",
+			subSuffix:    ".flag.go",
+			subRecursive: false,
+		},
+		{
+			name:         "flag overrides package",
+			args:         []string{"-recursive"},
+			recursive:    true,
+			lenient:      true,
+			suffix:       ".chaingen.go",
+			subSuffix:    ".sub.go",
+			subRecursive: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := newCommand()
+			err := cmd.flags.Parse(append([]string{"-src", dir}, tc.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			generator, err := cmd.generator()
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts, err := generator.PackageOptions(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.Recursive != tc.recursive || opts.Lenient != tc.lenient || opts.Cache != tc.cache || opts.FileSuffix != tc.suffix {
+				t.Fatalf("unexpected options: %+v", opts)
+			}
+			opts, err = generator.PackageOptions(sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.Recursive != tc.subRecursive || opts.FileSuffix != tc.subSuffix {
+				t.Fatalf("unexpected options of the sub package: %+v", opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+//go:build !chaingen
+// +build !chaingen
+
+// Code generated by chaingen. DO NOT EDIT.
+//chaingen:builders DSNBuilder,JoinedDSNBuilder
+
+package dsn_builder
+
+import (
+	"errors"
+)
+
+// Host sets the host
+func (d DSNBuilder) Host(host string) DSNBuilder {
+	if d.err != nil {
+		return d
+	}
+	child, err := d.A.Host(host)
+	if err != nil {
+		d.err = err
+		return d
+	}
+	d.A = child
+	return d
+}
+
+// Port sets the port
+func (d DSNBuilder) Port(port int) DSNBuilder {
+	if d.err != nil {
+		return d
+	}
+	child, err := d.A.Port(port)
+	if err != nil {
+		d.err = err
+		return d
+	}
+	d.A = child
+	return d
+}
+
+// Addr returns network address
+func (d DSNBuilder) Addr() (string, error) {
+	if d.err != nil {
+		return "", d.err
+	}
+	return d.A.Addr(), nil
+}
+
+// Host sets the host
+func (j JoinedDSNBuilder) Host(host string) JoinedDSNBuilder {
+	child, err := j.A.Host(host)
+	if err != nil {
+		j.err = errors.Join(j.err, err)
+	} else {
+		j.A = child
+	}
+	return j
+}
+
+// Port sets the port
+func (j JoinedDSNBuilder) Port(port int) JoinedDSNBuilder {
+	child, err := j.A.Port(port)
+	if err != nil {
+		j.err = errors.Join(j.err, err)
+	} else {
+		j.A = child
+	}
+	return j
+}
+
+// Addr returns network address
+func (j JoinedDSNBuilder) Addr() (string, error) {
+	if j.err != nil {
+		return "", j.err
+	}
+	return j.A.Addr(), nil
+}
@@ -0,0 +1,48 @@
+//go:generate go run github.com/AnatolyRugalev/chaingen -type DSNBuilder,JoinedDSNBuilder
+
+package dsn_builder
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+type AddrBuilder struct {
+	host string
+	port int
+}
+
+// Host sets the host
+func (a AddrBuilder) Host(host string) (AddrBuilder, error) {
+	if host == "" {
+		return a, fmt.Errorf("empty host")
+	}
+	a.host = host
+	return a, nil
+}
+
+// Port sets the port
+func (a AddrBuilder) Port(port int) (AddrBuilder, error) {
+	if port <= 0 || port > 65535 {
+		return a, fmt.Errorf("invalid port: %d", port)
+	}
+	a.port = port
+	return a, nil
+}
+
+// Addr returns network address
+func (a AddrBuilder) Addr() string {
+	return net.JoinHostPort(a.host, strconv.Itoa(a.port))
+}
+
+type DSNBuilder struct {
+	A   AddrBuilder
+	err error `chaingen:"errors"`
+}
+
+// JoinedDSNBuilder keeps errors of all chaining methods
+type JoinedDSNBuilder struct {
+	A   AddrBuilder
+	err error `chaingen:"errors(join)"`
+}
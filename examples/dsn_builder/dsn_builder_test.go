@@ -0,0 +1,31 @@
+package dsn_builder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDSNBuilder_Addr(t *testing.T) {
+	addr, err := DSNBuilder{}.Host("localhost").Port(5432).Addr()
+	if err != nil || addr != "localhost:5432" {
+		t.Fail()
+	}
+}
+
+func TestDSNBuilder_StickyError(t *testing.T) {
+	d := DSNBuilder{}.Host("localhost").Port(-1).Port(5432)
+	if d.A.port != 0 {
+		t.Fail()
+	}
+	_, err := d.Addr()
+	if err == nil || err.Error() != "invalid port: -1" {
+		t.Fail()
+	}
+}
+
+func TestJoinedDSNBuilder_StickyError(t *testing.T) {
+	_, err := JoinedDSNBuilder{}.Host("").Port(-1).Addr()
+	if err == nil || !strings.Contains(err.Error(), "empty host") || !strings.Contains(err.Error(), "invalid port: -1") {
+		t.Fail()
+	}
+}
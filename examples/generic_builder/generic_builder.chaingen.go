@@ -0,0 +1,40 @@
+//go:build !chaingen
+// +build !chaingen
+
+// Code generated by chaingen. DO NOT EDIT.
+//chaingen:builders Builder,Query,UserQuery
+
+package generic_builder
+
+// Where adds filter
+func (q Query[T]) Where(filter func(T) bool) Query[T] {
+	q.B = q.B.Where(filter)
+	return q
+}
+
+// Match reports whether the item passes all filters
+func (q Query[T]) Match(item T) bool {
+	return q.B.Match(item)
+}
+
+// Where adds filter
+func (u UserQuery) Where(filter func(User) bool) UserQuery {
+	u.Query = u.Query.Where(filter)
+	return u
+}
+
+// Match reports whether the item passes all filters
+func (u UserQuery) Match(item User) bool {
+	return u.Query.Match(item)
+}
+
+// Limit sets maximum amount of items
+func (u UserQuery) Limit(limit int) UserQuery {
+	u.Query = u.Query.Limit(limit)
+	return u
+}
+
+// Run returns matching items
+func (u UserQuery) Run(items []User) []User {
+	return u.Query.Run(items)
+}
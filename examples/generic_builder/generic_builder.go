@@ -0,0 +1,66 @@
+//go:generate go run github.com/AnatolyRugalev/chaingen -type UserQuery -recursive
+
+package generic_builder
+
+// Builder filters items
+type Builder[T any] struct {
+	filters []func(T) bool
+}
+
+// Where adds filter
+func (b Builder[T]) Where(filter func(T) bool) Builder[T] {
+	b.filters = append(b.filters[:len(b.filters):len(b.filters)], filter)
+	return b
+}
+
+// Match reports whether the item passes all filters
+func (b Builder[T]) Match(item T) bool {
+	for _, filter := range b.filters {
+		if !filter(item) {
+			return false
+		}
+	}
+	return true
+}
+
+type Query[T any] struct {
+	B     Builder[T]
+	limit int
+}
+
+// Limit sets maximum amount of items
+func (q Query[T]) Limit(limit int) Query[T] {
+	q.limit = limit
+	return q
+}
+
+// Run returns matching items
+func (q Query[T]) Run(items []T) []T {
+	var result []T
+	for _, item := range items {
+		if q.limit > 0 && len(result) == q.limit {
+			break
+		}
+		if q.B.Match(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+type User struct {
+	Name string
+	Age  int
+}
+
+type UserQuery struct {
+	Query[User]
+}
+
+// Adults returns adult users
+func (u UserQuery) Adults() UserQuery {
+	u.B = u.B.Where(func(user User) bool {
+		return user.Age >= 18
+	})
+	return u
+}
@@ -0,0 +1,33 @@
+package generic_builder
+
+import (
+	"testing"
+)
+
+var users = []User{
+	{Name: "Alice", Age: 30},
+	{Name: "Bob", Age: 12},
+	{Name: "Carol", Age: 45},
+	{Name: "Dave", Age: 19},
+}
+
+func TestQuery_Where(t *testing.T) {
+	q := Query[int]{}.
+		Where(func(i int) bool { return i > 1 }).
+		Limit(2)
+	result := q.Run([]int{1, 2, 3, 4})
+	if len(result) != 2 || result[0] != 2 || result[1] != 3 {
+		t.Fail()
+	}
+}
+
+func TestUserQuery_Run(t *testing.T) {
+	result := UserQuery{}.
+		Adults().
+		Where(func(user User) bool { return user.Name != "Alice" }).
+		Limit(1).
+		Run(users)
+	if len(result) != 1 || result[0].Name != "Carol" {
+		t.Fail()
+	}
+}
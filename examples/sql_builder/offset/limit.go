@@ -1,6 +1,7 @@
 package offset
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -8,9 +9,21 @@ import (
 )
 
 type LimitBuilder struct {
+	ctx   context.Context
 	limit int
 }
 
+// WithContext sets the context
+func (l LimitBuilder) WithContext(ctx context.Context) LimitBuilder {
+	l.ctx = ctx
+	return l
+}
+
+// Context returns the context
+func (l LimitBuilder) Context() context.Context {
+	return l.ctx
+}
+
 // Limit sets the limit
 // This is a second line of the comment
 func (l LimitBuilder) Limit(limit int) LimitBuilder {
@@ -23,6 +36,15 @@ func (l LimitBuilder) GetLimit() int {
 	return l.limit
 }
 
+// SafeLimit sets the limit if it is not negative
+func (l LimitBuilder) SafeLimit(limit int) (LimitBuilder, error) {
+	if limit < 0 {
+		return l, fmt.Errorf("negative limit: %d", limit)
+	}
+	l.limit = limit
+	return l, nil
+}
+
 func (l LimitBuilder) ChainMethodWithExternalType(param1 *log.Logger, param2 packages.Module) LimitBuilder {
 	return l
 }
@@ -31,6 +53,30 @@ func (l LimitBuilder) FinalizerWithExternalType(param1 fmt.Formatter, param2 pac
 	return param2
 }
 
+func (l LimitBuilder) ChainMethodWithComplexTypes(
+	handler func(*log.Logger) error,
+	results <-chan packages.Module,
+	formatters [2]fmt.Formatter,
+	options struct {
+		Logger *log.Logger `json:"logger"`
+	},
+	modules map[string]interface{ Load() packages.Module },
+) LimitBuilder {
+	return l
+}
+
+func (l LimitBuilder) ChainMethodWithCollidingParams(o, s string, _ int, log *log.Logger) LimitBuilder {
+	return l
+}
+
+func (l LimitBuilder) FallibleMethodWithCollidingParams(err error, child int) (LimitBuilder, error) {
+	return l, err
+}
+
+func (l LimitBuilder) FinalizerWithCollidingResults(packages string) (o int, l2 packages.Module) {
+	return 0, l2
+}
+
 func (l LimitBuilder) privateChainingMethod() LimitBuilder {
 	return l
 }
@@ -2,15 +2,35 @@
 // +build !chaingen
 
 // Code generated by chaingen. DO NOT EDIT.
+//chaingen:builders OffsetBuilder
 
 package offset
 
 import (
+	"context"
 	"fmt"
-	"golang.org/x/tools/go/packages"
 	"log"
+
+	"golang.org/x/tools/go/packages"
 )
 
+// Offset sets the offset param
+func (o OffsetBuilder) Offset(offset int) OffsetBuilder {
+	o.offset = offset
+	return o
+}
+
+// WithContext sets the context
+func (o OffsetBuilder) WithContext(ctx context.Context) OffsetBuilder {
+	o.L = o.L.WithContext(ctx)
+	return o
+}
+
+// Context returns the context
+func (o OffsetBuilder) Context() context.Context {
+	return o.L.Context()
+}
+
 // Limit sets the limit
 // This is a second line of the comment
 func (o OffsetBuilder) Limit(limit int) OffsetBuilder {
@@ -23,6 +43,16 @@ func (o OffsetBuilder) GetLimit() int {
 	return o.L.GetLimit()
 }
 
+// SafeLimit sets the limit if it is not negative
+func (o OffsetBuilder) SafeLimit(limit int) (OffsetBuilder, error) {
+	child, err := o.L.SafeLimit(limit)
+	if err != nil {
+		return o, err
+	}
+	o.L = child
+	return o, nil
+}
+
 func (o OffsetBuilder) ChainMethodWithExternalType(param1 *log.Logger, param2 packages.Module) OffsetBuilder {
 	o.L = o.L.ChainMethodWithExternalType(param1, param2)
 	return o
@@ -32,6 +62,31 @@ func (o OffsetBuilder) FinalizerWithExternalType(param1 fmt.Formatter, param2 pa
 	return o.L.FinalizerWithExternalType(param1, param2)
 }
 
+func (o OffsetBuilder) ChainMethodWithComplexTypes(handler func(*log.Logger) error, results <-chan packages.Module, formatters [2]fmt.Formatter, options struct {
+	Logger *log.Logger "json:\"logger\""
+}, modules map[string]interface{ Load() packages.Module }) OffsetBuilder {
+	o.L = o.L.ChainMethodWithComplexTypes(handler, results, formatters, options, modules)
+	return o
+}
+
+func (o OffsetBuilder) ChainMethodWithCollidingParams(p0 string, s string, p2 int, p3 *log.Logger) OffsetBuilder {
+	o.L = o.L.ChainMethodWithCollidingParams(p0, s, p2, p3)
+	return o
+}
+
+func (o OffsetBuilder) FallibleMethodWithCollidingParams(err error, child int) (OffsetBuilder, error) {
+	child1, err1 := o.L.FallibleMethodWithCollidingParams(err, child)
+	if err1 != nil {
+		return o, err1
+	}
+	o.L = child1
+	return o, nil
+}
+
+func (o OffsetBuilder) FinalizerWithCollidingResults(p0 string) (r0 int, l2 packages.Module) {
+	return o.L.FinalizerWithCollidingResults(p0)
+}
+
 func (o OffsetBuilder) privateChainingMethod() OffsetBuilder {
 	o.L = o.L.privateChainingMethod()
 	return o
@@ -5,14 +5,9 @@ import (
 )
 
 type OffsetBuilder struct {
-	L      LimitBuilder
-	offset int
-}
-
-// Offset sets the offset param
-func (o OffsetBuilder) Offset(offset int) OffsetBuilder {
-	o.offset = offset
-	return o
+	L LimitBuilder
+	// Offset sets the offset param
+	offset int `chaingen:"set"`
 }
 
 func (o OffsetBuilder) GetOffset() int {
@@ -2,22 +2,73 @@
 // +build !chaingen
 
 // Code generated by chaingen. DO NOT EDIT.
+//chaingen:builders OrderBuilder,SQLBuilder,WhereBuilder
 
 package sql_builder
 
 import (
+	"context"
 	"fmt"
-	"github.com/AnatolyRugalev/chaingen/examples/sql_builder/offset"
-	"golang.org/x/tools/go/packages"
 	"log"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/AnatolyRugalev/chaingen/examples/sql_builder/offset"
 )
 
+// Param sets named parameter of the condition
+func (w WhereBuilder) Param(key string, value any) WhereBuilder {
+	params := make(map[string]any, len(w.params)+1)
+	for k, v := range w.params {
+		params[k] = v
+	}
+	w.params = params
+	w.params[key] = value
+	return w
+}
+
+// Select adds columns to select
+func (s SQLBuilder) Select(columns ...string) SQLBuilder {
+	s.columns = append(s.columns[:len(s.columns):len(s.columns)], columns...)
+	return s
+}
+
+// WhereParam sets named parameter of the condition
+func (s SQLBuilder) WhereParam(key string, value any) SQLBuilder {
+	s.W = s.W.Param(key, value)
+	return s
+}
+
+// WithContext sets the context
+func (s SQLBuilder) WithContext(ctx context.Context) SQLBuilder {
+	s.W = s.W.WithContext(ctx)
+	s.O = s.O.WithContext(ctx)
+	return s
+}
+
 // Where sets SQL condition
 func (s SQLBuilder) Where(condition string) SQLBuilder {
 	s.W = s.W.Where(condition)
 	return s
 }
 
+// OrderBy adds column to sort by
+func (s SQLBuilder) OrderBy(column string) SQLBuilder {
+	s.OB.OrderBy(column)
+	return s
+}
+
+// Offset sets the offset param
+func (s SQLBuilder) Offset(offset int) SQLBuilder {
+	s.O = s.O.Offset(offset)
+	return s
+}
+
+// Context returns the context
+func (s SQLBuilder) Context() context.Context {
+	return s.O.Context()
+}
+
 // Limit sets the limit
 // This is a second line of the comment
 func (s SQLBuilder) Limit(limit int) SQLBuilder {
@@ -30,6 +81,16 @@ func (s SQLBuilder) GetLimit() error {
 	return s.wrapper(s.O.GetLimit())
 }
 
+// SafeLimit sets the limit if it is not negative
+func (s SQLBuilder) SafeLimit(limit int) (SQLBuilder, error) {
+	child, err := s.O.SafeLimit(limit)
+	if err != nil {
+		return s, err
+	}
+	s.O = child
+	return s, nil
+}
+
 func (s SQLBuilder) ChainMethodWithExternalType(param1 *log.Logger, param2 packages.Module) SQLBuilder {
 	s.O = s.O.ChainMethodWithExternalType(param1, param2)
 	return s
@@ -39,14 +100,33 @@ func (s SQLBuilder) FinalizerWithExternalType(param1 fmt.Formatter, param2 packa
 	return s.O.FinalizerWithExternalType(param1, param2)
 }
 
-func (s SQLBuilder) VariadicMethod(params ...string) SQLBuilder {
-	s.O = s.O.VariadicMethod(params...)
+func (s SQLBuilder) ChainMethodWithComplexTypes(handler func(*log.Logger) error, results <-chan packages.Module, formatters [2]fmt.Formatter, options struct {
+	Logger *log.Logger "json:\"logger\""
+}, modules map[string]interface{ Load() packages.Module }) SQLBuilder {
+	s.O = s.O.ChainMethodWithComplexTypes(handler, results, formatters, options, modules)
 	return s
 }
 
-// Offset sets the offset param
-func (s SQLBuilder) Offset(offset int) SQLBuilder {
-	s.O = s.O.Offset(offset)
+func (s SQLBuilder) ChainMethodWithCollidingParams(o string, p1 string, p2 int, p3 *log.Logger) SQLBuilder {
+	s.O = s.O.ChainMethodWithCollidingParams(o, p1, p2, p3)
+	return s
+}
+
+func (s SQLBuilder) FallibleMethodWithCollidingParams(err error, child int) (SQLBuilder, error) {
+	child1, err1 := s.O.FallibleMethodWithCollidingParams(err, child)
+	if err1 != nil {
+		return s, err1
+	}
+	s.O = child1
+	return s, nil
+}
+
+func (s SQLBuilder) FinalizerWithCollidingResults(p0 string) (o int, l2 packages.Module) {
+	return s.O.FinalizerWithCollidingResults(p0)
+}
+
+func (s SQLBuilder) VariadicMethod(params ...string) SQLBuilder {
+	s.O = s.O.VariadicMethod(params...)
 	return s
 }
 
@@ -58,3 +138,43 @@ func (s SQLBuilder) GetOffset() int {
 func (s SQLBuilder) Finalizer() *offset.OffsetBuilder {
 	return s.O.Finalizer()
 }
+
+// SQLBuilderAPI describes chained API of SQLBuilder
+type SQLBuilderAPI interface {
+	Build() string
+	// Select adds columns to select
+	Select(columns ...string) SQLBuilder
+	// WhereParam sets named parameter of the condition
+	WhereParam(key string, value any) SQLBuilder
+	// WithContext sets the context
+	WithContext(ctx context.Context) SQLBuilder
+	// Where sets SQL condition
+	Where(condition string) SQLBuilder
+	// OrderBy adds column to sort by
+	OrderBy(column string) SQLBuilder
+	// Offset sets the offset param
+	Offset(p0 int) SQLBuilder
+	// Context returns the context
+	Context() context.Context
+	// Limit sets the limit
+	// This is a second line of the comment
+	Limit(limit int) SQLBuilder
+	// GetLimit returns the limit
+	GetLimit() error
+	// SafeLimit sets the limit if it is not negative
+	SafeLimit(limit int) (SQLBuilder, error)
+	ChainMethodWithExternalType(param1 *log.Logger, param2 packages.Module) SQLBuilder
+	FinalizerWithExternalType(param1 fmt.Formatter, param2 packages.Package) packages.Package
+	ChainMethodWithComplexTypes(handler func(*log.Logger) error, results <-chan packages.Module, formatters [2]fmt.Formatter, options struct {
+		Logger *log.Logger "json:\"logger\""
+	}, modules map[string]interface{ Load() packages.Module }) SQLBuilder
+	ChainMethodWithCollidingParams(o string, p1 string, p2 int, p3 *log.Logger) SQLBuilder
+	FallibleMethodWithCollidingParams(err error, child int) (SQLBuilder, error)
+	FinalizerWithCollidingResults(p0 string) (int, packages.Module)
+	VariadicMethod(params ...string) SQLBuilder
+	GetOffset() int
+	// Finalizer is a useless finalizer that returns pointer to the builder
+	Finalizer() *offset.OffsetBuilder
+}
+
+var _ SQLBuilderAPI = SQLBuilder{}
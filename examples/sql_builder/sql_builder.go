@@ -3,6 +3,7 @@
 package sql_builder
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,7 +11,16 @@ import (
 )
 
 type WhereBuilder struct {
+	ctx        context.Context
 	conditions []string
+	// Param sets named parameter of the condition
+	params map[string]any `chaingen:"put=Param"`
+}
+
+// WithContext sets the context
+func (w WhereBuilder) WithContext(ctx context.Context) WhereBuilder {
+	w.ctx = ctx
+	return w
 }
 
 // Where sets SQL condition
@@ -23,14 +33,43 @@ func (w WhereBuilder) Build() string {
 	return fmt.Sprintf("WHERE %s", strings.Join(w.conditions, " AND "))
 }
 
+// OrderBuilder is a mutable builder
+type OrderBuilder struct {
+	columns []string
+}
+
+// OrderBy adds column to sort by
+func (o *OrderBuilder) OrderBy(column string) *OrderBuilder {
+	o.columns = append(o.columns, column)
+	return o
+}
+
+func (o *OrderBuilder) Build() string {
+	if len(o.columns) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ORDER BY %s", strings.Join(o.columns, ", "))
+}
+
+// chaingen:"api"
 type SQLBuilder struct {
-	// TODO: auto-resolve method conflicts if parent builder already has it anyway
-	W WhereBuilder         `chaingen:"-Build,*=Where*,*Where=*"`
-	O offset.OffsetBuilder `chaingen:"-Build,Offset*=*,fin(GetLimit)=wrapper"`
+	W  WhereBuilder         `chaingen:"-Build,*=Where*,*Where=*,WhereWithContext=WithContext"`
+	OB OrderBuilder         `chaingen:"-Build"`
+	O  offset.OffsetBuilder `chaingen:"conflict(fan-out),wrap(GetLimit)=wrapper"`
+	// Select adds columns to select
+	columns []string `chaingen:"append=Select"`
 }
 
 func (s SQLBuilder) Build() string {
-	return s.W.Build() + " " + s.O.Build()
+	var parts []string
+	if len(s.columns) > 0 {
+		parts = append(parts, "SELECT "+strings.Join(s.columns, ", "))
+	}
+	parts = append(parts, s.W.Build())
+	if order := s.OB.Build(); order != "" {
+		parts = append(parts, order)
+	}
+	return strings.Join(append(parts, s.O.Build()), " ")
 }
 
 func (s SQLBuilder) wrapper(params ...any) error {
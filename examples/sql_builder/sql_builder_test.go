@@ -1,6 +1,9 @@
 package sql_builder
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestSQLBuilder_Build(t *testing.T) {
 	s := SQLBuilder{}
@@ -13,3 +16,53 @@ func TestSQLBuilder_Build(t *testing.T) {
 		t.Fail()
 	}
 }
+
+type contextKey struct{}
+
+func TestSQLBuilder_WithContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+	s := SQLBuilder{}.WithContext(ctx)
+	if s.W.ctx != ctx || s.Context() != ctx {
+		t.Fail()
+	}
+}
+
+func TestSQLBuilder_OrderBy(t *testing.T) {
+	s := SQLBuilder{}
+	sql := s.
+		Where("id > 5").
+		OrderBy("id").
+		OrderBy("name").
+		Limit(10).
+		Build()
+	if sql != "WHERE id > 5 ORDER BY id, name LIMIT 10 OFFSET 0" {
+		t.Fail()
+	}
+}
+
+func TestSQLBuilder_SafeLimit(t *testing.T) {
+	s, err := SQLBuilder{}.SafeLimit(10)
+	if err != nil || s.O.L.GetLimit() != 10 {
+		t.Fail()
+	}
+	s, err = s.SafeLimit(-1)
+	if err == nil || s.O.L.GetLimit() != 10 {
+		t.Fail()
+	}
+}
+
+func TestSQLBuilder_Select(t *testing.T) {
+	s := SQLBuilder{}.Select("id", "name")
+	sql := s.Select("age").Where("id = 5").Build()
+	if sql != "SELECT id, name, age WHERE id = 5 LIMIT 0 OFFSET 0" || len(s.columns) != 2 {
+		t.Fail()
+	}
+}
+
+func TestSQLBuilder_WhereParam(t *testing.T) {
+	s := SQLBuilder{}.WhereParam("id", 5)
+	s2 := s.WhereParam("id", 6)
+	if s.W.params["id"] != 5 || s2.W.params["id"] != 6 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,130 @@
+//go:build !chaingen
+// +build !chaingen
+
+// Code generated by chaingen. DO NOT EDIT.
+//chaingen:builders QueryBuilder
+
+package step_builder
+
+// Select adds columns to select
+func (q QueryBuilder) Select(columns ...string) QueryBuilder {
+	q.S = q.S.Select(columns...)
+	return q
+}
+
+// From sets the table
+func (q QueryBuilder) From(table string) QueryBuilder {
+	q.S = q.S.From(table)
+	return q
+}
+
+// Where adds SQL condition
+func (q QueryBuilder) Where(condition string) QueryBuilder {
+	q.W = q.W.Where(condition)
+	return q
+}
+
+// Limit sets the limit
+func (q QueryBuilder) Limit(limit int) QueryBuilder {
+	q.L = q.L.Limit(limit)
+	return q
+}
+
+// Steps returns the initial stage of step builder
+func (q QueryBuilder) Steps() QueryBuilderStage0 {
+	return QueryBuilderStage0{b: q}
+}
+
+// QueryBuilderStage0 is a stage of QueryBuilder step builder
+type QueryBuilderStage0 struct {
+	b QueryBuilder
+}
+
+// Select adds columns to select
+func (q QueryBuilderStage0) Select(columns ...string) QueryBuilderStage1 {
+	q.b = q.b.Select(columns...)
+	return QueryBuilderStage1{b: q.b}
+}
+
+// QueryBuilderStage1 is a stage of QueryBuilder step builder
+type QueryBuilderStage1 struct {
+	b QueryBuilder
+}
+
+// Select adds columns to select
+func (q QueryBuilderStage1) Select(columns ...string) QueryBuilderStage1 {
+	q.b = q.b.Select(columns...)
+	return QueryBuilderStage1{b: q.b}
+}
+
+// From sets the table
+func (q QueryBuilderStage1) From(table string) QueryBuilderStage2 {
+	q.b = q.b.From(table)
+	return QueryBuilderStage2{b: q.b}
+}
+
+// QueryBuilderStage2 is a stage of QueryBuilder step builder
+type QueryBuilderStage2 struct {
+	b QueryBuilder
+}
+
+// Build builds SQL query
+func (q QueryBuilderStage2) Build() string {
+	return q.b.Build()
+}
+
+// From sets the table
+func (q QueryBuilderStage2) From(table string) QueryBuilderStage2 {
+	q.b = q.b.From(table)
+	return QueryBuilderStage2{b: q.b}
+}
+
+// Where adds SQL condition
+func (q QueryBuilderStage2) Where(condition string) QueryBuilderStage3 {
+	q.b = q.b.Where(condition)
+	return QueryBuilderStage3{b: q.b}
+}
+
+// Limit sets the limit
+func (q QueryBuilderStage2) Limit(limit int) QueryBuilderStage4 {
+	q.b = q.b.Limit(limit)
+	return QueryBuilderStage4{b: q.b}
+}
+
+// QueryBuilderStage3 is a stage of QueryBuilder step builder
+type QueryBuilderStage3 struct {
+	b QueryBuilder
+}
+
+// Build builds SQL query
+func (q QueryBuilderStage3) Build() string {
+	return q.b.Build()
+}
+
+// Where adds SQL condition
+func (q QueryBuilderStage3) Where(condition string) QueryBuilderStage3 {
+	q.b = q.b.Where(condition)
+	return QueryBuilderStage3{b: q.b}
+}
+
+// Limit sets the limit
+func (q QueryBuilderStage3) Limit(limit int) QueryBuilderStage4 {
+	q.b = q.b.Limit(limit)
+	return QueryBuilderStage4{b: q.b}
+}
+
+// QueryBuilderStage4 is a stage of QueryBuilder step builder
+type QueryBuilderStage4 struct {
+	b QueryBuilder
+}
+
+// Build builds SQL query
+func (q QueryBuilderStage4) Build() string {
+	return q.b.Build()
+}
+
+// Limit sets the limit
+func (q QueryBuilderStage4) Limit(limit int) QueryBuilderStage4 {
+	q.b = q.b.Limit(limit)
+	return QueryBuilderStage4{b: q.b}
+}
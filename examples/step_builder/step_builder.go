@@ -0,0 +1,64 @@
+//go:generate go run github.com/AnatolyRugalev/chaingen -type QueryBuilder
+
+package step_builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SelectBuilder struct {
+	columns []string
+	table   string
+}
+
+// Select adds columns to select
+func (s SelectBuilder) Select(columns ...string) SelectBuilder {
+	s.columns = append(s.columns[:len(s.columns):len(s.columns)], columns...)
+	return s
+}
+
+// From sets the table
+func (s SelectBuilder) From(table string) SelectBuilder {
+	s.table = table
+	return s
+}
+
+type WhereBuilder struct {
+	conditions []string
+}
+
+// Where adds SQL condition
+func (w WhereBuilder) Where(condition string) WhereBuilder {
+	w.conditions = append(w.conditions[:len(w.conditions):len(w.conditions)], condition)
+	return w
+}
+
+type LimitBuilder struct {
+	limit int
+}
+
+// Limit sets the limit
+func (l LimitBuilder) Limit(limit int) LimitBuilder {
+	l.limit = limit
+	return l
+}
+
+// chaingen:"stage(Select)=1,stage(From)=2,stage(Limit)=4?,stage(Build)=5"
+type QueryBuilder struct {
+	S SelectBuilder
+	W WhereBuilder `chaingen:"stage(Where)=3?"`
+	L LimitBuilder
+}
+
+// Build builds SQL query
+func (q QueryBuilder) Build() string {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(q.S.columns, ", "), q.S.table)
+	if len(q.W.conditions) > 0 {
+		query += " WHERE " + strings.Join(q.W.conditions, " AND ")
+	}
+	if q.L.limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.L.limit)
+	}
+	return query
+}
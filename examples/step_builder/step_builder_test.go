@@ -0,0 +1,28 @@
+package step_builder
+
+import (
+	"testing"
+)
+
+func TestQueryBuilder_Steps(t *testing.T) {
+	query := QueryBuilder{}.Steps().
+		Select("id", "name").
+		From("users").
+		Where("age > 18").
+		Where("name != ''").
+		Limit(10).
+		Build()
+	if query != "SELECT id, name FROM users WHERE age > 18 AND name != '' LIMIT 10" {
+		t.Fail()
+	}
+}
+
+func TestQueryBuilder_OptionalSteps(t *testing.T) {
+	query := QueryBuilder{}.Steps().
+		Select("id").
+		From("users").
+		Build()
+	if query != "SELECT id FROM users" {
+		t.Fail()
+	}
+}
@@ -1,10 +1,10 @@
 module github.com/AnatolyRugalev/chaingen
 
-go 1.19
+go 1.22.0
 
-require golang.org/x/tools v0.1.11
+require golang.org/x/tools v0.26.0
 
 require (
-	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
-	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
 )
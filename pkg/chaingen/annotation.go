@@ -0,0 +1,386 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// Modifier kinds. Function-like modifiers use their name as a kind
+const (
+	ModifierAll      = "*"
+	ModifierExclude  = "-"
+	ModifierRename   = "="
+	ModifierWrap     = "wrap"
+	ModifierPtr      = "ptr"
+	ModifierPre      = "pre"
+	ModifierPost     = "post"
+	ModifierExt      = "ext"
+	ModifierConflict = "conflict"
+	ModifierErrors   = "errors"
+	ModifierAPI      = "api"
+	ModifierStage    = "stage"
+	ModifierSet      = "set"
+	ModifierAppend   = "append"
+	ModifierPut      = "put"
+)
+
+// Annotation is a chaingen tag value found in a struct tag or a type comment
+type Annotation struct {
+	Value string
+	// Pos is a position of the first character of the value
+	Pos token.Pos
+}
+
+// Modifier is a single comma-separated element of an annotation
+type Modifier struct {
+	Kind     string
+	Text     string
+	Selector string
+	Value    string
+	HasValue bool
+	Pos      token.Pos
+	ValuePos token.Pos
+}
+
+var (
+	fieldModifiers = []string{ModifierAll, ModifierExclude, ModifierRename, ModifierWrap, ModifierPtr, ModifierPre, ModifierPost, ModifierConflict, ModifierStage}
+	typeModifiers  = []string{ModifierExt, ModifierAPI, ModifierStage}
+	// errorFieldModifiers are allowed in annotations of error fields
+	errorFieldModifiers = []string{ModifierErrors}
+	// setterModifiers are allowed in annotations of plain fields
+	setterModifiers = []string{ModifierSet, ModifierAppend, ModifierPut}
+	// keywordModifiers are modifiers that can be written without selector
+	keywordModifiers = []string{ModifierErrors, ModifierAPI, ModifierSet, ModifierAppend, ModifierPut}
+)
+
+type annotationParser struct {
+	fset    *token.FileSet
+	pos     token.Pos
+	src     string
+	allowed []string
+	strict  bool
+	errors  []error
+}
+
+// parseFieldAnnotation parses annotation of the child builder field
+func (c Chaingen) parseFieldAnnotation(fset *token.FileSet, annotation Annotation) ([]Modifier, []error) {
+	return parseAnnotation(fset, annotation, fieldModifiers, !c.opts.Lenient)
+}
+
+// parseErrorFieldAnnotation parses annotation of the error field
+func (c Chaingen) parseErrorFieldAnnotation(fset *token.FileSet, annotation Annotation) ([]Modifier, []error) {
+	return parseAnnotation(fset, annotation, errorFieldModifiers, !c.opts.Lenient)
+}
+
+// parseSetterFieldAnnotation parses annotation of the plain field
+func (c Chaingen) parseSetterFieldAnnotation(fset *token.FileSet, annotation Annotation) ([]Modifier, []error) {
+	return parseAnnotation(fset, annotation, setterModifiers, !c.opts.Lenient)
+}
+
+// parseTypeAnnotation parses builder type comment annotation
+func (c Chaingen) parseTypeAnnotation(fset *token.FileSet, annotation Annotation) ([]Modifier, []error) {
+	return parseAnnotation(fset, annotation, typeModifiers, !c.opts.Lenient)
+}
+
+// parseAnnotation parses annotation into the list of modifiers.
+// All syntax errors are collected and returned as diagnostics.
+// Modifiers of unknown kinds are reported in strict mode and ignored otherwise
+func parseAnnotation(fset *token.FileSet, annotation Annotation, allowed []string, strict bool) ([]Modifier, []error) {
+	p := &annotationParser{
+		fset:    fset,
+		pos:     annotation.Pos,
+		src:     annotation.Value,
+		allowed: allowed,
+		strict:  strict,
+	}
+	var modifiers []Modifier
+	for offset := 0; offset <= len(p.src); {
+		end := p.modifierEnd(offset)
+		if end > offset {
+			m, ok := p.parseModifier(offset, p.src[offset:end])
+			if ok {
+				modifiers = append(modifiers, m)
+			}
+		}
+		offset = end + 1
+	}
+	return modifiers, p.errors
+}
+
+func (p *annotationParser) errorf(offset int, format string, args ...any) {
+	d := Diagnostic{
+		Message: fmt.Sprintf(format, args...),
+	}
+	if pos := p.position(offset); pos.IsValid() {
+		d.Pos = p.fset.Position(pos)
+	}
+	p.errors = append(p.errors, d)
+}
+
+// modifierEnd returns offset of the comma that terminates modifier starting at the offset.
+// Commas inside parentheses of the modifier value don't terminate modifiers,
+// ext() modifier consumes the rest of annotation
+func (p *annotationParser) modifierEnd(offset int) int {
+	if strings.HasPrefix(p.src[offset:], ModifierExt+"(") {
+		return len(p.src)
+	}
+	depth := 0
+	selector, selectorDone := false, false
+	for i := offset; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '(':
+			if !selector && !selectorDone {
+				selector = true
+			} else {
+				depth++
+			}
+		case ')':
+			if selector {
+				selector, selectorDone = false, true
+			} else if depth > 0 {
+				depth--
+			}
+		case '=':
+			selectorDone = true
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(p.src)
+}
+
+func (p *annotationParser) parseModifier(offset int, text string) (Modifier, bool) {
+	m := Modifier{
+		Text: text,
+		Pos:  p.position(offset),
+	}
+	errs := len(p.errors)
+	open := strings.IndexByte(text, '(')
+	eq := strings.IndexByte(text, '=')
+	switch {
+	case text == "*":
+		m.Kind = ModifierAll
+	case oneOf(text, keywordModifiers):
+		m.Kind = text
+	case eq >= 0 && oneOf(text[:eq], setterModifiers):
+		m.Kind = text[:eq]
+		m.HasValue = true
+		m.Value = text[eq+1:]
+		m.ValuePos = p.position(offset + eq + 1)
+		p.checkIdent(offset+eq+1, m.Value)
+	case text[0] == '-':
+		m.Kind = ModifierExclude
+		m.Selector = text[1:]
+		if m.Selector != "" {
+			p.checkGlob(offset+1, m.Selector)
+		}
+	case open >= 0 && (eq < 0 || open < eq):
+		m.Kind = text[:open]
+		p.checkIdent(offset, m.Kind)
+		closing := strings.IndexByte(text[open+1:], ')')
+		if closing < 0 {
+			p.errorf(offset+len(text), "unexpected end of modifier %q, expected \")\"", text)
+			return m, false
+		}
+		closing += open + 1
+		m.Selector = text[open+1 : closing]
+		switch m.Kind {
+		case ModifierExt:
+			p.checkIdent(offset+open+1, m.Selector)
+		case ModifierConflict:
+			if _, err := ParseConflictStrategy(m.Selector); err != nil {
+				p.errorf(offset+open+1, "%s", err.Error())
+			}
+		case ModifierErrors:
+			if !oneOf(m.Selector, stickyModes) {
+				p.errorf(offset+open+1, "unknown sticky error mode %q, expected one of: %s", m.Selector, strings.Join(stickyModes, ", "))
+			}
+		default:
+			p.checkGlob(offset+open+1, m.Selector)
+		}
+		rest := text[closing+1:]
+		if rest != "" {
+			if rest[0] != '=' {
+				p.errorf(offset+closing+1, "unexpected token %q, expected \"=\"", rest[:1])
+				return m, false
+			}
+			m.HasValue = true
+			m.Value = rest[1:]
+			m.ValuePos = p.position(offset + closing + 2)
+		}
+		p.checkValue(offset+closing+2, m)
+	default:
+		m.Kind = ModifierRename
+		m.Selector = text
+		if eq >= 0 {
+			m.Selector = text[:eq]
+			m.HasValue = true
+			m.Value = text[eq+1:]
+			m.ValuePos = p.position(offset + eq + 1)
+			p.checkGlob(offset+eq+1, m.Value)
+		}
+		p.checkGlob(offset, m.Selector)
+	}
+	if len(p.errors) > errs {
+		return m, false
+	}
+	if oneOf(m.Kind, p.allowed) {
+		return m, true
+	}
+	if p.strict {
+		p.errorf(offset, "unknown modifier %q, expected one of: %s", m.Text, strings.Join(modifierForms(p.allowed), ", "))
+	}
+	return m, false
+}
+
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func modifierForms(kinds []string) []string {
+	forms := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		switch kind {
+		case ModifierAll:
+			forms = append(forms, "*")
+		case ModifierExclude:
+			forms = append(forms, "-selector")
+		case ModifierRename:
+			forms = append(forms, "Old=New")
+		case ModifierPtr:
+			forms = append(forms, "ptr(selector)")
+		case ModifierExt:
+			forms = append(forms, "ext(Method)=annotation")
+		case ModifierConflict:
+			forms = append(forms, "conflict(strategy)")
+		case ModifierErrors:
+			forms = append(forms, "errors(mode)")
+		case ModifierAPI:
+			forms = append(forms, "api")
+		case ModifierStage:
+			forms = append(forms, "stage(selector)=N")
+		case ModifierSet, ModifierAppend, ModifierPut:
+			forms = append(forms, kind, kind+"=Method")
+		default:
+			forms = append(forms, kind+"(selector)=value")
+		}
+	}
+	return forms
+}
+
+func (p *annotationParser) checkValue(offset int, m Modifier) {
+	switch m.Kind {
+	case ModifierWrap:
+		if !m.HasValue {
+			p.errorf(offset-1, "modifier %q requires wrapper method name: wrap(selector)=wrapper", m.Text)
+			return
+		}
+		for _, wrapper := range strings.Split(m.Value, "|") {
+			p.checkIdent(offset, wrapper)
+			offset += len(wrapper) + 1
+		}
+	case ModifierStage:
+		if !m.HasValue {
+			p.errorf(offset-1, "modifier %q requires stage number: stage(selector)=N", m.Text)
+		} else if _, err := parseStage(m.Value); err != nil {
+			p.errorf(offset, "%s", err.Error())
+		}
+	case ModifierPre, ModifierPost:
+		if !m.HasValue || m.Value == "" {
+			p.errorf(offset-1, "modifier %q requires code: %s(selector)=code", m.Text, m.Kind)
+		}
+	case ModifierPtr, ModifierConflict, ModifierErrors:
+		if m.HasValue {
+			p.errorf(offset-1, "unexpected token \"=\", modifier %q has no value", m.Text)
+		}
+	}
+}
+
+func (p *annotationParser) checkIdent(offset int, ident string) {
+	if ident == "" {
+		p.errorf(offset, "unexpected end of identifier")
+		return
+	}
+	for i, r := range ident {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			p.errorf(offset+i, "unexpected token %q in identifier %q", r, ident)
+			return
+		}
+	}
+}
+
+// checkGlob validates method selector: optional type name followed by a dot and a method name glob
+func (p *annotationParser) checkGlob(offset int, glob string) {
+	if glob == "" {
+		p.errorf(offset, "unexpected end of selector")
+		return
+	}
+	if dot := strings.IndexByte(glob, '.'); dot >= 0 {
+		p.checkIdent(offset, glob[:dot])
+		offset += dot + 1
+		glob = glob[dot+1:]
+		if glob == "" {
+			p.errorf(offset, "unexpected end of selector")
+			return
+		}
+	}
+	stars := strings.Count(glob, "*")
+	for i, r := range glob {
+		if r != '*' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			p.errorf(offset+i, "unexpected token %q in selector %q", r, glob)
+			return
+		}
+	}
+	if stars > 2 || stars == 2 && (glob[0] != '*' || glob[len(glob)-1] != '*' || len(glob) < 3) {
+		p.errorf(offset, "unsupported selector %q: only one wildcard or *middle* form is allowed", glob)
+	}
+}
+
+func (p *annotationParser) position(offset int) token.Pos {
+	if !p.pos.IsValid() {
+		return token.NoPos
+	}
+	return p.pos + token.Pos(offset)
+}
+
+// tagValuePos returns position of the struct tag value of the field declared at fieldPos
+func tagValuePos(file *ast.File, fieldPos token.Pos, key string) token.Pos {
+	pos := token.NoPos
+	ast.Inspect(file, func(n ast.Node) bool {
+		if pos.IsValid() {
+			return false
+		}
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		match := len(field.Names) == 0 && field.Type.Pos() <= fieldPos && fieldPos < field.Type.End()
+		for _, name := range field.Names {
+			match = match || name.Pos() == fieldPos
+		}
+		if match {
+			pos = valuePos(field.Tag.Pos(), field.Tag.Value, key)
+		}
+		return true
+	})
+	return pos
+}
+
+// valuePos returns position of the tag value with given key in the text starting at pos
+func valuePos(pos token.Pos, text string, key string) token.Pos {
+	i := strings.Index(text, key+`:"`)
+	if i < 0 {
+		return pos
+	}
+	return pos + token.Pos(i+len(key)+2)
+}
@@ -0,0 +1,90 @@
+package chaingen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		annotation string
+		allowed    []string
+		modifiers  string
+		err        string
+	}{
+		{annotation: "*", allowed: fieldModifiers, modifiers: "*()"},
+		{annotation: "*,-Build", allowed: fieldModifiers, modifiers: "*() -(Build)"},
+		{annotation: "Limit=SetLimit", allowed: fieldModifiers, modifiers: "=(Limit)=SetLimit"},
+		{annotation: "wrap(Get*)=wrapper|log", allowed: fieldModifiers, modifiers: "wrap(Get*)=wrapper|log"},
+		{annotation: "pre(*)=fmt.Println(a, b),*", allowed: fieldModifiers, modifiers: "pre(*)=fmt.Println(a, b) *()"},
+		{annotation: "conflict(fan-out)", allowed: fieldModifiers, modifiers: "conflict(fan-out)"},
+		{annotation: "stage(Where)=2?", allowed: fieldModifiers, modifiers: "stage(Where)=2?"},
+		{annotation: "ext(Offset)=*,-Build", allowed: typeModifiers, modifiers: "ext(Offset)=*,-Build"},
+		{annotation: "errors(join)", allowed: errorFieldModifiers, modifiers: "errors(join)"},
+		{annotation: "append=Select,put", allowed: setterModifiers, modifiers: "append()=Select put()"},
+		{annotation: "wrap(Get*)", allowed: fieldModifiers, err: `modifier "wrap(Get*)" requires wrapper method name`},
+		{annotation: "ptr(Get", allowed: fieldModifiers, err: `unexpected end of modifier "ptr(Get", expected ")"`},
+		{annotation: "ptr(Get)x", allowed: fieldModifiers, err: `unexpected token "x", expected "="`},
+		{annotation: "conflict(unknown)", allowed: fieldModifiers, err: `unknown conflict strategy`},
+		{annotation: "errors(all)", allowed: errorFieldModifiers, err: `unknown sticky error mode "all"`},
+		{annotation: "stage(Where)=0", allowed: fieldModifiers, err: `invalid stage "0"`},
+		{annotation: "api", allowed: fieldModifiers, err: `unknown modifier "api"`},
+		{annotation: "Foo)(x", allowed: fieldModifiers, err: `unexpected end of modifier "Foo)(x", expected ")"`},
+		{annotation: "AA)0(0", allowed: fieldModifiers, err: `unexpected end of modifier`},
+		{annotation: "0)00(00000", allowed: fieldModifiers, err: `unexpected end of modifier`},
+	} {
+		t.Run(tc.annotation, func(t *testing.T) {
+			modifiers, errs := parseAnnotation(nil, Annotation{Value: tc.annotation}, tc.allowed, true)
+			if tc.err != "" {
+				if !strings.Contains(fmt.Sprint(errs), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, errs)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			var parsed []string
+			for _, m := range modifiers {
+				s := m.Kind + "(" + m.Selector + ")"
+				if m.HasValue {
+					s += "=" + m.Value
+				}
+				parsed = append(parsed, s)
+			}
+			if strings.Join(parsed, " ") != tc.modifiers {
+				t.Fatalf("unexpected modifiers %q, expected %q", strings.Join(parsed, " "), tc.modifiers)
+			}
+		})
+	}
+}
+
+func FuzzParseAnnotation(f *testing.F) {
+	for _, seed := range []string{"*,-Build", "wrap(Get*)=wrapper", "pre(*)=f(a, b)", "Foo)(x", "AA)0(0", "0)00(00000"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, annotation string) {
+		for _, allowed := range [][]string{fieldModifiers, typeModifiers, errorFieldModifiers, setterModifiers} {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("panic parsing %q: %v", annotation, r)
+					}
+				}()
+				parseAnnotation(nil, Annotation{Value: annotation}, allowed, true)
+			}()
+		}
+	})
+}
+
+func TestParseAnnotationStrictByDefault(t *testing.T) {
+	_, errs := New(Options{}).parseFieldAnnotation(nil, Annotation{Value: "unknown(*)=x"})
+	if len(errs) == 0 {
+		t.Fatal("unknown modifier is not reported")
+	}
+	_, errs = New(Options{Lenient: true}).parseFieldAnnotation(nil, Annotation{Value: "unknown(*)=x"})
+	if len(errs) != 0 {
+		t.Fatalf("unknown modifier is reported in lenient mode: %v", errs)
+	}
+}
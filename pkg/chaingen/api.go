@@ -0,0 +1,76 @@
+package chaingen
+
+import (
+	"go/token"
+	"strings"
+)
+
+// APIName returns name of the interface describing builder API
+func (b *Builder) APIName() string {
+	return b.Type.Obj().Name() + "API"
+}
+
+// RenderAPI renders interface type that covers exported hand-written and generated methods of the builder.
+// Go has no covariant result types, so chaining methods return the builder type itself
+func (b *Builder) RenderAPI(file *File) {
+	tps := b.Type.TypeParams()
+	var tpDecls []string
+	for i := 0; i < tps.Len(); i++ {
+		tp := tps.At(i)
+		tpDecls = append(tpDecls, tp.Obj().Name()+" "+file.TypeIdentifier(tp.Constraint()))
+	}
+	name := b.APIName()
+	if len(tpDecls) > 0 {
+		name += "[" + strings.Join(tpDecls, ", ") + "]"
+	}
+	file.L()
+	file.L("// " + b.APIName() + " describes chained API of " + b.Type.Obj().Name())
+	file.L("type " + name + " interface {")
+	for _, method := range append(b.Methods[:len(b.Methods):len(b.Methods)], b.GeneratedMethods...) {
+		if !token.IsExported(method.Alias) {
+			continue
+		}
+		if doc := method.Doc(); doc != nil {
+			for _, line := range doc.List {
+				file.L("\t" + line.Text)
+			}
+		}
+		file.L("\t" + method.Alias + b.apiSignature(file, method))
+	}
+	file.L("}")
+	// Generic builders can't be asserted without instantiation
+	if len(tpDecls) > 0 {
+		return
+	}
+	// Methods generated by ptr(...) modifier are declared with pointer receivers too
+	pointer := b.Pointer
+	for _, method := range b.GeneratedMethods {
+		pointer = pointer || method.Pointer
+	}
+	file.L()
+	switch {
+	case pointer:
+		file.L("var _ " + b.APIName() + " = (*" + b.Type.Obj().Name() + ")(nil)")
+	case b.Struct != nil:
+		file.L("var _ " + b.APIName() + " = " + b.Type.Obj().Name() + "{}")
+	default:
+		file.L("var _ " + b.APIName() + " = " + b.Type.Obj().Name() + "(nil)")
+	}
+}
+
+// apiSignature returns method signature with parameter names
+func (b *Builder) apiSignature(file *File, method Method) string {
+	params, _ := b.renderParams(file, method)
+	var results []string
+	for _, param := range method.Results {
+		results = append(results, file.TypeIdentifier(param.Type))
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + results[0]
+	}
+	return sig + " (" + strings.Join(results, ", ") + ")"
+}
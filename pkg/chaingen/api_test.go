@@ -0,0 +1,59 @@
+package chaingen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderAPI(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type Child struct{ n int }\n\n" +
+		"// Set sets the number\n" +
+		"func (c Child) Set(n int) Child { c.n = n; return c }\n\n" +
+		"// chaingen:\"api\"\n" +
+		"type Parent struct {\n\tC Child `chaingen:\"*=Child*\"`\n}\n\n" +
+		"// Names sets names\n" +
+		"// of the parent\n" +
+		"func (p Parent) Names(names ...string) Parent { return p }\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	files, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(files[filepath.Join(dir, "m.chaingen.go")])
+	for _, s := range []string{
+		"// ChildSet sets the number\nfunc (p Parent) ChildSet(n int) Parent {",
+		"type ParentAPI interface {\n" +
+			"\t// Names sets names\n\t// of the parent\n\tNames(names ...string) Parent\n" +
+			"\t// ChildSet sets the number\n\tChildSet(n int) Parent\n" +
+			"}",
+	} {
+		if !strings.Contains(content, s) {
+			t.Fatalf("generated code doesn't contain %q:\n%s", s, content)
+		}
+	}
+}
+
+func TestRenderAPIPointerMethods(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type C struct{ n int }\n\n" +
+		"func (c C) One() C { c.n = 1; return c }\n\n" +
+		"// chaingen:\"api\"\n" +
+		"type A struct {\n\tC C `chaingen:\"ptr(One)\"`\n}\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "A"
+	files, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(files[filepath.Join(dir, "m.chaingen.go")])
+	for _, s := range []string{"func (a *A) One() *A {", "var _ AAPI = (*A)(nil)"} {
+		if !strings.Contains(content, s) {
+			t.Fatalf("generated code doesn't contain %q:\n%s", s, content)
+		}
+	}
+}
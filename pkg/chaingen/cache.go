@@ -0,0 +1,267 @@
+package chaingen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cacheVersion invalidates cache entries written by incompatible versions of the cache
+const cacheVersion = 1
+
+// cacheMaxAge is a period after which unused cache entries are removed
+const cacheMaxAge = 30 * 24 * time.Hour
+
+// cacheEntry records inputs and outputs of the successful generation.
+// Generation is skipped if inputs are unchanged and outputs are in place
+type cacheEntry struct {
+	// Inputs are hashes of source files of loaded packages, their non-standard dependencies and module files
+	Inputs map[string]string `json:"inputs"`
+	// PatternDirs are directories with Go files matched by recursive package patterns
+	PatternDirs []string `json:"pattern_dirs"`
+	// Outputs are hashes of generated files
+	Outputs map[string]string `json:"outputs"`
+	Orphans []string          `json:"orphans"`
+	Dirs    []string          `json:"dirs"`
+	Deps    []string          `json:"deps"`
+}
+
+// cachePath returns path of the cache entry for the options, generator executable and Go version.
+// Empty string is returned if there is no user cache directory.
+// The entry covers the whole invocation: skipping unchanged builders only would still require loading the source code,
+// which takes most of the generation time
+func (c Chaingen) cachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	key := struct {
+		Version    int
+		Go         string
+		Executable string
+		Options    Options
+		// Package options are resolved from the config and base options
+		Config *Config
+		Base   Options
+	}{
+		Version: cacheVersion,
+		Go:      runtime.Version(),
+		Options: c.opts,
+		Config:  c.config,
+		Base:    c.base,
+	}
+	// Binaries built by go run may be kept at the same path after the generator code changes
+	if path, err := os.Executable(); err == nil {
+		key.Executable = hashFile(path)
+	}
+	h := sha256.New()
+	err = json.NewEncoder(h).Encode(key)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "chaingen", hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+// cached returns directories of builder packages if the cache entry is up to date
+func (c Chaingen) cached(path string) (map[string]bool, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	entry := cacheEntry{}
+	if json.Unmarshal(data, &entry) != nil {
+		return nil, false
+	}
+	dirs := make(map[string]bool, len(entry.Dirs))
+	for _, dir := range entry.Dirs {
+		dirs[dir] = true
+	}
+	deps := make(map[string]bool, len(entry.Deps))
+	for _, dir := range entry.Deps {
+		deps[dir] = true
+	}
+	if !sameHashes(entry.Inputs, hashFiles(c.inputFiles(dirs, deps))) {
+		return nil, false
+	}
+	if strings.Join(entry.PatternDirs, "\n") != strings.Join(c.patternDirs(), "\n") {
+		return nil, false
+	}
+	for path, hash := range entry.Outputs {
+		if hashFile(path) != hash {
+			return nil, false
+		}
+	}
+	if c.opts.RemoveOrphans {
+		for _, path := range entry.Orphans {
+			if isGenerated(path) {
+				return nil, false
+			}
+		}
+	}
+	// Entries that are in use are kept by pruneCache
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return dirs, true
+}
+
+// storeCache records the generation. Entry isn't written if input files were modified during the generation
+func (c Chaingen) storeCache(path string, gen *generation, start time.Time) error {
+	entry := cacheEntry{
+		Inputs:      map[string]string{},
+		PatternDirs: c.patternDirs(),
+		Outputs:     map[string]string{},
+		Orphans:     gen.orphans,
+	}
+	for dir := range gen.dirs {
+		entry.Dirs = append(entry.Dirs, dir)
+	}
+	sort.Strings(entry.Dirs)
+	for dir := range gen.deps {
+		entry.Deps = append(entry.Deps, dir)
+	}
+	sort.Strings(entry.Deps)
+	for _, file := range c.inputFiles(gen.dirs, gen.deps) {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Before(start) {
+			return nil
+		}
+		entry.Inputs[file] = hashFile(file)
+	}
+	for dest, content := range gen.files {
+		sum := sha256.Sum256(content)
+		entry.Outputs[dest] = hex.EncodeToString(sum[:])
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return err
+	}
+	return pruneCache(filepath.Dir(path), time.Now().Add(-cacheMaxAge))
+}
+
+// pruneCache removes cache entries that were neither written nor used since the deadline
+func pruneCache(dir string, deadline time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(deadline) {
+			continue
+		}
+		err = os.Remove(filepath.Join(dir, entry.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// inputFiles returns source files of the directories along with go.mod and go.sum of the module
+func (c Chaingen) inputFiles(dirs map[string]bool, deps map[string]bool) []string {
+	all := make(map[string]bool, len(dirs)+len(deps))
+	for dir := range dirs {
+		all[dir] = true
+	}
+	for dir := range deps {
+		all[dir] = true
+	}
+	files := c.sourceFiles(all)
+	dir := c.opts.Src
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			files = append(files, filepath.Join(dir, "go.mod"), filepath.Join(dir, "go.sum"))
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return files
+}
+
+// patternDirs returns directories containing Go files that are matched by recursive package patterns.
+// New packages matched by the patterns invalidate the cache
+func (c Chaingen) patternDirs() []string {
+	var dirs []string
+	for _, pattern := range c.opts.Patterns {
+		root := strings.TrimSuffix(pattern, "...")
+		if root == pattern {
+			continue
+		}
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(c.opts.Src, root)
+		}
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name := entry.Name()
+			if entry.IsDir() && path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && strings.HasSuffix(name, ".go") {
+				dirs = append(dirs, filepath.Dir(path))
+			}
+			return nil
+		})
+	}
+	sort.Strings(dirs)
+	unique := dirs[:0]
+	for i, dir := range dirs {
+		if i == 0 || dirs[i-1] != dir {
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// hashFiles returns hashes of the files. Missing files have empty hash
+func hashFiles(files []string) map[string]string {
+	hashes := make(map[string]string, len(files))
+	for _, file := range files {
+		hashes[file] = hashFile(file)
+	}
+	return hashes
+}
+
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func sameHashes(left, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for path, hash := range left {
+		if other, ok := right[path]; !ok || other != hash {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package chaingen
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := writeModule(t, map[string]string{
+		"m.go": "package m\n\n" +
+			"import \"example.com/m/tt\"\n\n" +
+			"type Child struct{ v tt.Value }\n\n" +
+			"func (c Child) Set(v tt.Value) Child { c.v = v; return c }\n\n" +
+			"type Parent struct {\n\tC Child\n}\n",
+		"tt/tt.go": "package tt\n\ntype Value int\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	opts.Cache = true
+	c := New(opts)
+	path := c.cachePath()
+	if path == "" {
+		t.Fatal("cache path is empty")
+	}
+	generate := func() {
+		t.Helper()
+		err := c.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := c.cached(path); !ok {
+			t.Fatal("expected cache hit after generation")
+		}
+	}
+	if _, ok := c.cached(path); ok {
+		t.Fatal("expected cache miss before generation")
+	}
+	generate()
+	for _, tc := range []struct {
+		name   string
+		change func() error
+	}{
+		{
+			name: "builder source",
+			change: func() error {
+				return os.WriteFile(filepath.Join(dir, "m.go"), append(readFile(t, filepath.Join(dir, "m.go")), "\n// changed\n"...), 0644)
+			},
+		},
+		{
+			name: "dependency source",
+			change: func() error {
+				return os.WriteFile(filepath.Join(dir, "tt/tt.go"), []byte("package tt\n\ntype Value int64\n"), 0644)
+			},
+		},
+		{
+			name: "new dependency file",
+			change: func() error {
+				return os.WriteFile(filepath.Join(dir, "tt/other.go"), []byte("package tt\n\nconst Zero Value = 0\n"), 0644)
+			},
+		},
+		{
+			name: "generated file",
+			change: func() error {
+				return os.WriteFile(filepath.Join(dir, "m.chaingen.go"), []byte("package m\n"), 0644)
+			},
+		},
+		{
+			name: "go.mod",
+			change: func() error {
+				return os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.22.0\n"), 0644)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.change()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := c.cached(path); ok {
+				t.Fatal("expected cache miss")
+			}
+			generate()
+		})
+	}
+}
+
+func TestCacheCheck(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := writeModule(t, map[string]string{
+		"m.go": "package m\n\n" +
+			"type Child struct{ n int }\n\n" +
+			"func (c Child) Set(n int) Child { c.n = n; return c }\n\n" +
+			"type Parent struct {\n\tC Child\n}\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	opts.Cache = true
+	err := New(opts).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	for _, mode := range []string{"check", "diff"} {
+		opts := opts
+		opts.Check = mode == "check"
+		opts.Diff = mode == "diff"
+		err = New(opts).Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(buf.String(), "up to date") {
+			t.Fatalf("%s mode used the cache: %s", mode, buf.String())
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	deadline := time.Now().Add(-cacheMaxAge)
+	for name, modTime := range map[string]time.Time{
+		"old.json":   deadline.Add(-time.Hour),
+		"fresh.json": deadline.Add(time.Hour),
+		"other.txt":  deadline.Add(-time.Hour),
+	} {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte("{}"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chtimes(path, modTime, modTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := pruneCache(dir, deadline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, exists := range map[string]bool{"old.json": false, "fresh.json": true, "other.txt": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) == exists {
+			t.Fatalf("%s: expected exists=%t", name, exists)
+		}
+	}
+}
@@ -9,16 +9,22 @@ import (
 	"go/types"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/tools/go/packages"
 )
 
 type Chaingen struct {
 	opts Options
+	// config holds per-package overrides that are applied on top of base options for every loaded package
+	config *Config
+	base   Options
 }
 
 func New(opts Options) Chaingen {
@@ -27,6 +33,35 @@ func New(opts Options) Chaingen {
 	}
 }
 
+// NewWithConfig returns generator that applies the config to options of every loaded package,
+// so package overrides take effect for package patterns as well
+func NewWithConfig(opts Options, config *Config) (Chaingen, error) {
+	c := Chaingen{
+		config: config,
+		base:   opts,
+	}
+	var err error
+	c.opts, err = c.PackageOptions(opts.Src)
+	return c, err
+}
+
+// PackageOptions returns options of the package located in the directory
+func (c Chaingen) PackageOptions(dir string) (Options, error) {
+	if c.config == nil {
+		return c.opts, nil
+	}
+	opts := c.base
+	opts.Src = dir
+	// Type annotations of the config are added to the map
+	opts.TypeAnnotations = maps.Clone(opts.TypeAnnotations)
+	err := c.config.Apply(&opts)
+	if err != nil {
+		return opts, err
+	}
+	opts.Src = c.base.Src
+	return opts, nil
+}
+
 // Builder represents a struct type that is considered to be a builder.
 // Builder has at least one method that returns altered Builder copy (chaining method).
 // Every non-chaining method is considered as finalizer
@@ -34,7 +69,7 @@ type Builder struct {
 	PkgPath          string
 	Package          *packages.Package
 	Type             *types.Named
-	Annotations      []string
+	Annotations      []Annotation
 	Struct           *types.Struct
 	Methods          []Method
 	Children         []*BuilderRef
@@ -44,13 +79,35 @@ type Builder struct {
 	Rendered         bool
 	GeneratedMethods []Method
 	Depth            int
+	// Pointer is true for mutable builders that have methods with pointer receivers
+	Pointer bool
+	// Conflicts are reports of resolved method naming conflicts
+	Conflicts []Diagnostic
+	// StickyError is a field that accumulates errors of fallible chaining methods
+	StickyError *StickyError
+	// API is true if interface describing builder API is requested by annotation
+	API bool
+	// StageModifiers declare stages of builder methods
+	StageModifiers []Modifier
+	// Setters are chaining methods generated from plain fields
+	Setters []Setter
+	// Errors holds diagnostics of malformed annotations
+	Errors []error
 }
 
 type BuilderRef struct {
 	Name            string
 	IsMethod        bool
 	FieldAnnotation string
-	Builder         *Builder
+	Modifiers       []Modifier
+	Conflict        ConflictStrategy
+	// Pos is a position of the field or ext modifier
+	Pos token.Pos
+	// Pointer is true if the field or external builder method has pointer type
+	Pointer bool
+	// Type is a type of the field or external builder method result. Generic children are instantiated
+	Type    *types.Named
+	Builder *Builder
 }
 
 type Import struct {
@@ -74,6 +131,14 @@ type Method struct {
 	Postfixes   []string
 	WrapperName string
 	Pointer     bool
+	// Ref is a child builder reference the method is proxied to
+	Ref *BuilderRef
+	// FanOut holds methods of other children that are called along with this chaining method
+	FanOut []Method
+	// Stage is a step of the builder call order the method belongs to
+	Stage Stage
+	// Comment is a doc comment of the generated method taken from the proxied method
+	Comment *ast.CommentGroup
 }
 
 func (m Method) String() string {
@@ -119,8 +184,10 @@ func NewMethod(builder *Builder, f *types.Func, sig *types.Signature) Method {
 }
 
 func (b *Builder) ReceiverName() string {
-	if len(b.Methods) > 0 {
-		return b.Methods[0].Recv.Name
+	for _, m := range b.Methods {
+		if m.Recv.Name != "" && m.Recv.Name != "_" {
+			return m.Recv.Name
+		}
 	}
 	return strings.ToLower(b.Type.Obj().Name()[0:1])
 }
@@ -135,7 +202,7 @@ func (b *Builder) ReceiverType(ptr bool) string {
 	var tpss []string
 	for i := 0; i < tps.Len(); i++ {
 		tp := tps.At(i)
-		tpss = append(tpss, tp.String())
+		tpss = append(tpss, tp.Obj().Name())
 	}
 	if len(tpss) > 0 {
 		s.WriteString("[" + strings.Join(tpss, ", ") + "]")
@@ -152,19 +219,38 @@ func (b *Builder) Ref(builder *Builder) *BuilderRef {
 	return nil
 }
 
-func (b *Builder) RenderChainMethod(file *File, method Method) {
+// renderParams returns input parameters declaration and call arguments of the method.
+// Unnamed, blank and colliding parameters are renamed to p0, p1, etc.
+func (b *Builder) renderParams(file *File, method Method) ([]string, []string) {
+	file.Idents = map[string]bool{
+		b.ReceiverName(): true,
+	}
+	names := make([]string, len(method.Params))
+	for i, param := range method.Params {
+		name := param.Name
+		for k := i; name == "" || name == "_" || file.Idents[name] || file.ImportAliases[name] != nil; k++ {
+			name = fmt.Sprintf("p%d", k)
+		}
+		file.Idents[name] = true
+		names[i] = name
+	}
 	var inputParams []string
 	var callParams []string
 	for i, param := range method.Params {
 		last := i == len(method.Params)-1
 		if last && method.Variadic {
-			inputParams = append(inputParams, param.Name+" ..."+file.TypeIdentifier(param.Type.(*types.Slice).Elem()))
-			callParams = append(callParams, param.Name+"...")
+			inputParams = append(inputParams, names[i]+" ..."+file.TypeIdentifier(param.Type.(*types.Slice).Elem()))
+			callParams = append(callParams, names[i]+"...")
 		} else {
-			inputParams = append(inputParams, param.Name+" "+file.TypeIdentifier(param.Type))
-			callParams = append(callParams, param.Name)
+			inputParams = append(inputParams, names[i]+" "+file.TypeIdentifier(param.Type))
+			callParams = append(callParams, names[i])
 		}
 	}
+	return inputParams, callParams
+}
+
+func (b *Builder) RenderChainMethod(file *File, method Method) {
+	inputParams, callParams := b.renderParams(file, method)
 	file.L()
 	doc := method.Doc()
 	if doc != nil {
@@ -172,45 +258,123 @@ func (b *Builder) RenderChainMethod(file *File, method Method) {
 			file.L(line.Text)
 		}
 	}
-	ref := b.ReceiverName() + "." + b.Ref(method.Builder).Name
-	file.L(`func (` + b.ReceiverName() + ` ` + b.ReceiverType(method.Pointer) + `) ` + method.Alias + `(` + strings.Join(inputParams, ", ") + `) ` + b.ReceiverType(method.Pointer) + " {")
-	for _, prefix := range method.Prefixes {
-		file.L(prefix)
+	ptr := method.Pointer || b.Pointer
+	outputParamsStr := b.ReceiverType(ptr)
+	errName := ""
+	if method.IsFallible() {
+		errName = file.Ident("err")
+	}
+	if b.IsFallible(method) {
+		outputParamsStr = "(" + outputParamsStr + ", error)"
+	}
+	file.L(`func (` + b.ReceiverName() + ` ` + b.ReceiverType(ptr) + `) ` + method.Alias + `(` + strings.Join(inputParams, ", ") + `) ` + outputParamsStr + " {")
+	b.renderStickyGuard(file)
+	// Children are assigned after every fan-out target succeeded, so errors are returned along with unchanged parent
+	targets := append([]Method{method}, method.FanOut...)
+	var assigns []string
+	for _, target := range targets {
+		for _, prefix := range target.Prefixes {
+			file.L(prefix)
+		}
+		ref := b.ReceiverName() + "." + target.Ref.Name
+		assign := b.renderChainCall(file, target, ref, target.Ref.Pointer, strings.Join(callParams, ", "), errName, func() {
+			b.renderErrorReturn(file, errName)
+		})
+		if assign != "" {
+			assigns = append(assigns, assign)
+		}
 	}
-	file.L("\t" + ref + ` = ` + ref + `.` + method.Name + `(` + strings.Join(callParams, ", ") + `)`)
-	for _, postfix := range method.Postfixes {
-		file.L(postfix)
+	for _, assign := range assigns {
+		file.L("\t" + assign)
+	}
+	for _, target := range targets {
+		for _, postfix := range target.Postfixes {
+			file.L(postfix)
+		}
+	}
+	if b.IsFallible(method) {
+		file.L("\treturn " + b.ReceiverName() + ", nil")
+	} else {
+		file.L("\treturn " + b.ReceiverName())
 	}
-	file.L("\treturn " + b.ReceiverName())
 	file.L("}")
 }
 
-func (b *Builder) RenderFinalizer(file *File, method Method) {
-	var inputParams []string
-	var callParams []string
-	for i, param := range method.Params {
-		last := i == len(method.Params)-1
-		if last && method.Variadic {
-			inputParams = append(inputParams, param.Name+" ..."+file.TypeIdentifier(param.Type.(*types.Slice).Elem()))
-			callParams = append(callParams, param.Name+"...")
+// renderChainCall renders call of the chaining method that updates the builder stored in ref.
+// Pointer chaining methods mutate the builder in place, results of value chaining methods are assigned back.
+// errReturn renders handling of the error returned by fallible chaining method. Assignment of the result of
+// fallible method is returned to be rendered by the caller once all calls succeeded
+func (b *Builder) renderChainCall(file *File, method Method, ref string, pointer bool, args string, errName string, errReturn func()) string {
+	call := ref + "." + method.Name + "(" + args + ")"
+	assign := ref + " = "
+	if pointer {
+		assign = "*" + assign
+	}
+	if !method.IsFallible() {
+		if isPointer(method.Results[0].Type) {
+			file.L("\t" + call)
 		} else {
-			inputParams = append(inputParams, param.Name+" "+file.TypeIdentifier(param.Type))
-			callParams = append(callParams, param.Name)
+			file.L("\t" + assign + call)
 		}
+		return ""
+	}
+	if isPointer(method.Results[0].Type) {
+		file.L("\tif _, " + errName + " := " + call + "; " + errName + " != nil {")
+		errReturn()
+		file.L("\t}")
+		return ""
+	}
+	child := file.Ident("child")
+	file.L("\t" + child + ", " + errName + " := " + call)
+	file.L("\tif " + errName + " != nil {")
+	errReturn()
+	if b.StickyError != nil && b.StickyError.Mode == StickyJoin {
+		file.L("\t} else {")
+		file.L("\t\t" + assign + child)
+		file.L("\t}")
+		return ""
+	}
+	file.L("\t}")
+	return assign + child
+}
+
+// renderErrorReturn renders handling of the error returned by fallible chaining method
+func (b *Builder) renderErrorReturn(file *File, errName string) {
+	if b.StickyError != nil {
+		b.renderStickyRecord(file, errName)
+		return
 	}
+	file.L("\t\treturn " + b.ReceiverName() + ", " + errName)
+}
+
+func (b *Builder) RenderFinalizer(file *File, method Method) {
+	inputParams, callParams := b.renderParams(file, method)
+	// Finalizers of builders with sticky error return it along with the results
+	appendErr := b.StickyError != nil && !endsWithError(method.Results)
+	named := false
 	var outputParams []string
-	for _, param := range method.Results {
+	for i, param := range method.Results {
 		typeName := file.TypeIdentifier(param.Type)
-		if typeName == "" {
-			// TODO: error
-			return
-		}
 		name := param.Name
+		if name != "" && name != "_" {
+			for k := i; file.Idents[name] || file.ImportAliases[name] != nil; k++ {
+				name = fmt.Sprintf("r%d", k)
+			}
+			file.Idents[name] = true
+		}
 		if name != "" {
+			named = true
 			name += " "
 		}
 		outputParams = append(outputParams, name+typeName)
 	}
+	if appendErr {
+		name := ""
+		if named {
+			name = file.Ident("err") + " "
+		}
+		outputParams = append(outputParams, name+"error")
+	}
 	outputParamsStr := strings.Join(outputParams, ", ")
 	if len(outputParams) > 1 {
 		outputParamsStr = "(" + outputParamsStr + ")"
@@ -222,8 +386,18 @@ func (b *Builder) RenderFinalizer(file *File, method Method) {
 			file.L(line.Text)
 		}
 	}
-	ref := b.ReceiverName() + "." + b.Ref(method.Builder).Name
-	file.L(`func (` + b.ReceiverName() + ` ` + b.ReceiverType(method.Pointer) + `) ` + method.Alias + `(` + strings.Join(inputParams, ", ") + `) ` + outputParamsStr + " {")
+	ref := b.ReceiverName() + "." + method.Ref.Name
+	file.L(`func (` + b.ReceiverName() + ` ` + b.ReceiverType(method.Pointer || b.Pointer) + `) ` + method.Alias + `(` + strings.Join(inputParams, ", ") + `) ` + outputParamsStr + " {")
+	if b.StickyError != nil {
+		zeros := []string{}
+		for _, param := range method.Results {
+			zeros = append(zeros, zeroValue(file, param.Type))
+		}
+		if !appendErr {
+			zeros = zeros[:len(zeros)-1]
+		}
+		b.renderStickyGuard(file, zeros...)
+	}
 	for _, prefix := range method.Prefixes {
 		file.L(prefix)
 	}
@@ -232,48 +406,102 @@ func (b *Builder) RenderFinalizer(file *File, method Method) {
 		file.L(postfix)
 		file.L("}()")
 	}
-	if len(outputParams) > 0 {
-		file.P("return ")
-	}
+	call := ref + "." + method.Name + "(" + strings.Join(callParams, ", ") + ")"
 	if method.WrapperName != "" {
-		file.P(b.ReceiverName(), ".", method.WrapperName, "(")
+		call = b.ReceiverName() + "." + method.WrapperName + "(" + call + ")"
 	}
-	file.P(ref, `.`, method.Name, `(`, strings.Join(callParams, ", "), `)`)
-	if method.WrapperName != "" {
-		file.P(")")
+	switch {
+	case !appendErr && len(outputParams) > 0:
+		file.L("\treturn " + call)
+	case !appendErr:
+		file.L("\t" + call)
+	case len(method.Results) == 0:
+		file.L("\t" + call)
+		file.L("\treturn nil")
+	case len(method.Results) == 1:
+		file.L("\treturn " + call + ", nil")
+	default:
+		var results []string
+		for i := range method.Results {
+			results = append(results, file.Ident(fmt.Sprintf("r%d", i)))
+		}
+		file.L("\t" + strings.Join(results, ", ") + " := " + call)
+		file.L("\treturn " + strings.Join(results, ", ") + ", nil")
 	}
 	file.L("}")
 }
 
+// IsChaining reports whether the method returns altered builder: func (T) M() T.
+// Methods of mutable builders are chaining as well: func (*T) M() *T
 func (m Method) IsChaining() bool {
-	if len(m.Results) != 1 {
+	return len(m.Results) == 1 && m.returnsBuilder()
+}
+
+// IsFallible reports whether the method is a chaining method that can fail: func (T) M() (T, error)
+func (m Method) IsFallible() bool {
+	return len(m.Results) == 2 && m.returnsBuilder() && types.Identical(m.Results[1].Type, errorType)
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// returnsBuilder reports whether the first result of the method is the builder itself
+func (m Method) returnsBuilder() bool {
+	if len(m.Results) == 0 {
 		return false
 	}
-	return m.Results[0].Type == m.Builder.Type
+	recv := m.Recv.Type
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	if ptr, ok := m.Results[0].Type.(*types.Pointer); ok {
+		return isPointer(m.Recv.Type) && types.Identical(ptr.Elem(), recv)
+	}
+	return types.Identical(m.Results[0].Type, recv)
+}
+
+// IsChainingRef reports whether the method is a chaining method that can be proxied through the child reference.
+// Only pointer chaining methods can be proxied through external builder methods
+func (m Method) IsChainingRef() bool {
+	if m.Ref == nil || !m.IsChaining() && !m.IsFallible() {
+		return false
+	}
+	if m.Ref.IsMethod {
+		return m.Ref.Pointer && isPointer(m.Results[0].Type)
+	}
+	return true
 }
 
 func (m Method) IsFinalizer() bool {
 	if m.WrapperName != "" {
 		return true
 	}
-	return !m.IsChaining()
+	return !m.IsChaining() && !m.IsFallible()
 }
 
 const generatedPrefix = "Code generated by chaingen. DO NOT EDIT."
 
+// buildersPrefix starts the header line that lists builder types of the generated file
+const buildersPrefix = "//chaingen:builders "
+
 func (m Method) Doc() *ast.CommentGroup {
+	if m.Comment != nil {
+		return m.Comment
+	}
 	pkg := m.Builder.Package
 	methodPos := pkg.Fset.Position(m.Pos)
 	for _, file := range pkg.Syntax {
 		for _, cg := range file.Comments {
 			commentPos := pkg.Fset.Position(cg.End())
 			if commentPos.Filename == methodPos.Filename && commentPos.Line == methodPos.Line-1 {
-				if m.Name != m.Alias {
-					firstLine := cg.List[0].Text
-					if strings.HasPrefix(firstLine, "// "+m.Name+" ") {
-						firstLine = "// " + m.Alias + " " + firstLine[len(m.Name)+4:]
-						cg.List[0].Text = firstLine
+				firstLine := cg.List[0].Text
+				if m.Name != m.Alias && strings.HasPrefix(firstLine, "// "+m.Name+" ") {
+					// Comments are copied because the syntax tree is shared between builders
+					renamed := &ast.CommentGroup{List: append([]*ast.Comment{}, cg.List...)}
+					renamed.List[0] = &ast.Comment{
+						Slash: cg.List[0].Slash,
+						Text:  "// " + m.Alias + " " + firstLine[len(m.Name)+4:],
 					}
+					return renamed
 				}
 				return cg
 			}
@@ -283,13 +511,29 @@ func (m Method) Doc() *ast.CommentGroup {
 }
 
 type Options struct {
-	Src           string
-	TypeName      string
-	Recursive     bool
-	FileSuffix    string
-	ErrOnConflict bool
-	StructTag     string
-	BuildTag      string
+	Src               string
+	TypeName          string
+	Recursive         bool
+	FileSuffix        string
+	ErrOnConflict     bool
+	Conflict          ConflictStrategy
+	StructTag         string
+	BuildTag          string
+	Check             bool
+	Diff              bool
+	RemoveOrphans     bool
+	ErrOnUnrenderable bool
+	// Lenient makes annotation parser ignore unknown modifiers instead of reporting them
+	Lenient bool
+	// API enables generation of interfaces describing builders API
+	API bool
+	// Cache enables skipping generation if source files, options and generated files are unchanged since the last run
+	Cache bool
+	// Patterns are package patterns relative to Src, e.g. ./... Src package is loaded if empty
+	Patterns []string
+	// TypeAnnotations are added to type comment annotations of builders.
+	// Keys are type names, optionally qualified by package path
+	TypeAnnotations map[string]string
 }
 
 type File struct {
@@ -301,22 +545,30 @@ type File struct {
 
 	Imports       map[string]Import
 	ImportAliases map[string]*Import
-	Body          bytes.Buffer
+	// Idents holds receiver and parameter names of the method being rendered
+	Idents map[string]bool
+	Body   bytes.Buffer
 }
 
 func (f *File) Render(w io.Writer) error {
 	output := "//go:build !" + f.BuildTag + "\n"
 	output += "// +build !" + f.BuildTag + "\n\n"
-	output += "// " + generatedPrefix + "\n\n"
+	output += "// " + generatedPrefix + "\n"
+	output += buildersPrefix + strings.Join(f.builderNames(), ",") + "\n\n"
 	output += "package " + f.Package.Name + "\n\n"
 	if len(f.Imports) > 0 {
 		output += "import (\n"
-		for _, i := range f.Imports {
-			alias := ""
-			if i.Alias != i.Package.Name() {
-				alias = i.Alias + " "
+		for i, group := range f.importGroups() {
+			if i > 0 {
+				output += "\n"
+			}
+			for _, imp := range group {
+				alias := ""
+				if imp.Alias != imp.Package.Name() {
+					alias = imp.Alias + " "
+				}
+				output += "\t" + alias + `"` + imp.Package.Path() + `"` + "\n"
 			}
-			output += "\t" + alias + `"` + i.Package.Path() + `"` + "\n"
 		}
 		output += ")\n"
 	}
@@ -324,6 +576,63 @@ func (f *File) Render(w io.Writer) error {
 	return err
 }
 
+// builderNames returns sorted names of builder types rendered into the file
+func (f *File) builderNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, builder := range f.Builders {
+		name := builder.Type.Obj().Name()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// importGroups returns sorted imports grouped goimports-style:
+// standard library, third-party packages and packages of the current module
+func (f *File) importGroups() [][]Import {
+	var std, thirdParty, local []Import
+	module := ""
+	if f.Package.Module != nil {
+		module = f.Package.Module.Path
+	}
+	for _, i := range f.Imports {
+		path := i.Package.Path()
+		switch {
+		case module != "" && (path == module || strings.HasPrefix(path, module+"/")):
+			local = append(local, i)
+		case !strings.Contains(strings.Split(path, "/")[0], "."):
+			std = append(std, i)
+		default:
+			thirdParty = append(thirdParty, i)
+		}
+	}
+	var groups [][]Import
+	for _, group := range [][]Import{std, thirdParty, local} {
+		if len(group) == 0 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Package.Path() < group[j].Package.Path()
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// Ident returns identifier that doesn't collide with parameters of the method being rendered and imports
+func (f *File) Ident(name string) string {
+	ident := name
+	for k := 1; f.Idents[ident] || f.ImportAliases[ident] != nil; k++ {
+		ident = fmt.Sprintf("%s%d", name, k)
+	}
+	f.Idents[ident] = true
+	return ident
+}
+
 func (f *File) P(s ...string) {
 	f.Body.WriteString(strings.Join(s, ""))
 }
@@ -332,33 +641,17 @@ func (f *File) L(s ...string) {
 	f.P(append(s, "\n")...)
 }
 
+// TypeIdentifier returns type expression that is valid within the file.
+// Packages of referenced types are imported automatically
 func (f *File) TypeIdentifier(typ types.Type) string {
-	switch t := typ.(type) {
-	case *types.Named:
-		pkg := t.Obj().Pkg()
-		pkgPath := ""
-		if pkg != nil && pkg.Path() != f.Package.PkgPath {
-			pkgPath = f.PackageIdentifier(pkg) + "."
-		}
-
-		typeArgs := ""
-		if ta := t.TypeArgs(); ta != nil {
-			var args []string
-			for i := 0; i < ta.Len(); i++ {
-				args = append(args, f.TypeIdentifier(ta.At(i)))
-			}
-			typeArgs = "[" + strings.Join(args, ", ") + "]"
-		}
-		return pkgPath + t.Obj().Name() + typeArgs
-	case *types.Slice:
-		return "[]" + f.TypeIdentifier(t.Elem())
-	case *types.Map:
-		return "map[" + f.TypeIdentifier(t.Key()) + "]" + f.TypeIdentifier(t.Elem())
-	case *types.Pointer:
-		return "*" + f.TypeIdentifier(t.Elem())
-	default:
-		return t.String()
+	return types.TypeString(typ, f.qualifier)
+}
+
+func (f *File) qualifier(pkg *types.Package) string {
+	if pkg.Path() == f.Package.PkgPath {
+		return ""
 	}
+	return f.PackageIdentifier(pkg)
 }
 
 func (f *File) PackageIdentifier(pkg *types.Package) string {
@@ -370,23 +663,19 @@ func (f *File) PackageIdentifier(pkg *types.Package) string {
 		Alias:   pkg.Name(),
 		Package: pkg,
 	}
-	f.Imports[pkg.Path()] = i
 	suffix := 0
-	for {
-		_, ok := f.ImportAliases[i.Alias]
-		if !ok {
-			break
-		}
+	for f.ImportAliases[i.Alias] != nil || f.Idents[i.Alias] || f.Package.Types.Scope().Lookup(i.Alias) != nil {
 		suffix++
 		i.Alias = fmt.Sprintf("%s%d", pkg.Name(), suffix)
 	}
+	f.Imports[pkg.Path()] = i
 	f.ImportAliases[i.Alias] = &i
 	return i.Alias
 }
 
 func (c Chaingen) Render(builders map[*types.Named]*Builder) (map[string]*File, error) {
 	files := make(map[string]*File)
-	for _, builder := range builders {
+	for _, builder := range sortedBuilders(builders) {
 		if builder.Depth > 0 {
 			continue
 		}
@@ -398,120 +687,143 @@ func (c Chaingen) Render(builders map[*types.Named]*Builder) (map[string]*File,
 	return files, nil
 }
 
-func (c Chaingen) evalAnnotations(tag string, methods []Method, builderMethods []Method) ([]Method, error) {
-	if tag == "" || tag == "*" {
-		return methods, nil
-	}
-	if tag == "-" {
-		return nil, nil
-	}
-	pool := make(map[string]Method, len(methods))
-	for _, method := range methods {
-		pool[method.Alias] = method
+// sortedBuilders returns builders ordered by their declaration position
+func sortedBuilders(builders map[*types.Named]*Builder) []*Builder {
+	sorted := make([]*Builder, 0, len(builders))
+	for _, builder := range builders {
+		sorted = append(sorted, builder)
 	}
-	modifiers := strings.Split(tag, ",")
+	sort.Slice(sorted, func(i, j int) bool {
+		left := sorted[i].Package.Fset.Position(sorted[i].Type.Obj().Pos())
+		right := sorted[j].Package.Fset.Position(sorted[j].Type.Obj().Pos())
+		if left.Filename != right.Filename {
+			return left.Filename < right.Filename
+		}
+		if left.Offset != right.Offset {
+			return left.Offset < right.Offset
+		}
+		// Instances of the same generic type
+		return sorted[i].Type.String() < sorted[j].Type.String()
+	})
+	return sorted
+}
+
+// sortMethods orders methods by declaration position. Positions are compared by file name and offset
+// because bases of files in the file set depend on the order in which files were parsed
+func sortMethods(fset *token.FileSet, methods []Method) {
+	sort.SliceStable(methods, func(i, j int) bool {
+		left, right := fset.Position(methods[i].Pos), fset.Position(methods[j].Pos)
+		if left.Filename != right.Filename {
+			return left.Filename < right.Filename
+		}
+		return left.Offset < right.Offset
+	})
+}
+
+func (c Chaingen) evalAnnotations(builder *Builder, modifiers []Modifier, methods []Method) []Method {
+	pool := make([]Method, len(methods))
+	copy(pool, methods)
 	for _, modifier := range modifiers {
-		if len(modifier) == 0 {
+		matched := 0
+		switch modifier.Kind {
+		case ModifierAll, ModifierConflict:
 			continue
-		}
-		parts := strings.Split(modifier, "=")
-		switch {
-		case modifier == "*":
-			break
-		case modifier[0] == '-':
-			delete(pool, modifier[1:])
-		case strings.HasPrefix(modifier, "wrap("):
-			selector := parts[0][5 : len(parts[0])-1]
-			glob := NewGlob(selector)
-			newPool := make(map[string]Method, len(pool))
-			wrappers := strings.Split(parts[1], "|")
+		case ModifierExclude:
+			glob := NewGlob(modifier.Selector)
+			filtered := pool[:0]
 			for _, method := range pool {
+				if modifier.Selector == "" || !glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
+					filtered = append(filtered, method)
+				}
+			}
+			matched = len(pool) - len(filtered)
+			pool = filtered
+		case ModifierWrap:
+			glob := NewGlob(modifier.Selector)
+			wrappers := strings.Split(modifier.Value, "|")
+			for i, method := range pool {
+				if !glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
+					continue
+				}
+				matched++
 				for _, wrapperName := range wrappers {
-					if glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
-						for _, m := range builderMethods {
-							if m.Name == wrapperName {
-								compatible := m.Variadic
-								if !compatible && len(m.Params) == len(method.Results) {
-									compatible = true
-									for i := 0; i < len(m.Params); i++ {
-										if m.Params[i].Type.String() != method.Results[i].Type.String() {
-											compatible = false
-											break
-										}
+					for _, m := range builder.Methods {
+						if m.Name == wrapperName {
+							compatible := m.Variadic
+							if !compatible && len(m.Params) == len(method.Results) {
+								compatible = true
+								for i := 0; i < len(m.Params); i++ {
+									if m.Params[i].Type.String() != method.Results[i].Type.String() {
+										compatible = false
+										break
 									}
 								}
-								if compatible {
-									method.WrapperName = wrapperName
-									method.Results = m.Results
-								}
-								break
 							}
+							if compatible {
+								method.WrapperName = wrapperName
+								method.Results = m.Results
+							}
+							break
 						}
 					}
-					newPool[method.Alias] = method
 				}
+				pool[i] = method
 			}
-			pool = newPool
-		case strings.HasPrefix(modifier, "ptr("):
-			selector := parts[0][4 : len(parts[0])-1]
-			glob := NewGlob(selector)
-			newPool := make(map[string]Method, len(pool))
-			for _, method := range pool {
+		case ModifierPtr:
+			glob := NewGlob(modifier.Selector)
+			for i, method := range pool {
 				if glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
-					method.Pointer = true
+					matched++
+					pool[i].Pointer = true
 				}
-				newPool[method.Alias] = method
 			}
-			pool = newPool
-		case strings.HasPrefix(modifier, "pre("):
-			selector := parts[0][4 : len(parts[0])-1]
-			glob := NewGlob(selector)
-			newPool := make(map[string]Method, len(pool))
-			for _, method := range pool {
+		case ModifierStage:
+			glob := NewGlob(modifier.Selector)
+			stage, _ := parseStage(modifier.Value)
+			for i, method := range pool {
 				if glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
-					method.Prefixes = append(method.Prefixes, parts[1])
+					matched++
+					pool[i].Stage = stage
 				}
-				newPool[method.Alias] = method
 			}
-			pool = newPool
-		case strings.HasPrefix(modifier, "post("):
-			selector := parts[0][5 : len(parts[0])-1]
-			glob := NewGlob(selector)
-			newPool := make(map[string]Method, len(pool))
-			for _, method := range pool {
+		case ModifierPre:
+			glob := NewGlob(modifier.Selector)
+			for i, method := range pool {
 				if glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
-					method.Postfixes = append(method.Postfixes, parts[1])
+					matched++
+					pool[i].Prefixes = append(method.Prefixes[:len(method.Prefixes):len(method.Prefixes)], modifier.Value)
 				}
-				newPool[method.Alias] = method
 			}
-			pool = newPool
-		default:
-			left := NewGlob(parts[0])
+		case ModifierPost:
+			glob := NewGlob(modifier.Selector)
+			for i, method := range pool {
+				if glob.Match(method.Recv.Named.Obj().Name(), method.Alias) {
+					matched++
+					pool[i].Postfixes = append(method.Postfixes[:len(method.Postfixes):len(method.Postfixes)], modifier.Value)
+				}
+			}
+		case ModifierRename:
+			left := NewGlob(modifier.Selector)
 			var right *Glob
-			if len(parts) > 1 {
-				rightGlob := NewGlob(parts[1])
+			if modifier.HasValue {
+				rightGlob := NewGlob(modifier.Value)
 				right = &rightGlob
 			}
-			newPool := make(map[string]Method, len(pool))
-			for _, method := range pool {
+			for i, method := range pool {
 				if left.Match(method.Recv.Named.Obj().Name(), method.Alias) {
-					method.Alias = left.Replace(method.Alias, right)
+					matched++
+					pool[i].Alias = left.Replace(method.Alias, right)
 				}
-				newPool[method.Alias] = method
 			}
-			pool = newPool
 		}
-	}
-	result := make([]Method, 0, len(pool))
-	for _, original := range methods {
-		for _, altered := range pool {
-			if original.Pos == altered.Pos {
-				result = append(result, altered)
-				break
-			}
+		if matched == 0 {
+			log.Printf("warning: %s", Diagnostic{
+				Pos:     builder.Package.Fset.Position(modifier.Pos),
+				Message: fmt.Sprintf("modifier %q matched no methods", modifier.Text),
+			}.Error())
 		}
 	}
-	return result, nil
+	return pool
 }
 
 type Glob struct {
@@ -612,6 +924,7 @@ func (c Chaingen) render(files map[string]*File, builder *Builder) error {
 			Path:          builder.FilePath,
 			Imports:       map[string]Import{},
 			ImportAliases: map[string]*Import{},
+			Idents:        map[string]bool{},
 		}
 		files[builder.FilePath] = file
 	}
@@ -620,73 +933,206 @@ func (c Chaingen) render(files map[string]*File, builder *Builder) error {
 		return nil
 	}
 	builder.Rendered = true
+	for _, setter := range builder.Setters {
+		for i := 0; i < builder.Struct.NumFields(); i++ {
+			if field := builder.Struct.Field(i); field.Name() == setter.Name {
+				return Diagnostic{
+					Pos:     builder.Package.Fset.Position(setter.Field.Pos()),
+					Message: fmt.Sprintf("setter %s.%s of field %s conflicts with field %s", builder.Type.Obj().Name(), setter.Name, setter.Field.Name(), field.Name()),
+				}
+			}
+		}
+		for _, own := range builder.Methods {
+			if own.Name == setter.Name {
+				return Diagnostic{
+					Pos:     builder.Package.Fset.Position(setter.Field.Pos()),
+					Message: fmt.Sprintf("setter %s.%s of field %s conflicts with %s", builder.Type.Obj().Name(), setter.Name, setter.Field.Name(), own.String()),
+				}
+			}
+		}
+		if _, ok := builder.MethodNames[setter.Name]; ok {
+			return Diagnostic{
+				Pos:     builder.Package.Fset.Position(setter.Field.Pos()),
+				Message: fmt.Sprintf("setter %s.%s of field %s is declared more than once", builder.Type.Obj().Name(), setter.Name, setter.Field.Name()),
+			}
+		}
+		method := setter.Method(builder)
+		builder.MethodNames[setter.Name] = &method
+	}
+	var planned []*Method
 	for _, child := range builder.Children {
-		var methods []Method
 		if c.opts.Recursive {
 			err := c.render(files, child.Builder)
 			if err != nil {
 				return err
 			}
-			methods = append(methods, child.Builder.GeneratedMethods...)
-		}
-		methods = append(methods, child.Builder.Methods...)
-
-		methods, err := c.evalAnnotations(child.FieldAnnotation, methods, builder.Methods)
-		if err != nil {
-			return err
 		}
+		methods := c.evalAnnotations(builder, child.Modifiers, child.Methods(c.opts.Recursive))
 		for _, m := range methods {
 			if !m.Exported && m.Builder.PkgPath != builder.PkgPath {
 				continue
 			}
-			if conflict, ok := builder.MethodNames[m.Alias]; ok {
-				if c.opts.ErrOnConflict {
-					return fmt.Errorf("method naming conflict for %s.%s: %s and %s", builder.Type.Obj().Name(), m.Alias, conflict.String(), m.String())
-				}
+			m.Ref = child
+			m.FanOut = nil
+			if !m.IsChainingRef() && !m.IsFinalizer() {
 				continue
 			}
-			switch {
-			case !child.IsMethod && m.IsChaining():
-				builder.RenderChainMethod(file, m)
-				builder.MethodNames[m.Alias] = &m
-				generated := m
-				generated.Name = generated.Alias
-				generated.Results = []MethodParam{
-					{
-						Type: builder.Type,
-					},
+			err := builder.checkRenderable(m)
+			if err != nil {
+				if c.opts.ErrOnUnrenderable {
+					return err
 				}
-				generated.Builder = builder
-				builder.GeneratedMethods = append(builder.GeneratedMethods, generated)
-			case m.IsFinalizer():
-				builder.RenderFinalizer(file, m)
-				builder.MethodNames[m.Alias] = &m
-				generated := m
-				generated.Name = generated.Alias
-				generated.Builder = builder
-				builder.GeneratedMethods = append(builder.GeneratedMethods, generated)
+				log.Printf("warning: %s", err.Error())
+				continue
+			}
+			// Conflicts are resolved between methods that are going to be generated
+			ok, err := c.resolveConflict(builder, child, m)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
 			}
+			method := m
+			builder.MethodNames[m.Alias] = &method
+			planned = append(planned, &method)
 		}
 	}
 
+	for _, setter := range builder.Setters {
+		builder.GeneratedMethods = append(builder.GeneratedMethods, builder.RenderSetter(file, setter))
+	}
+	for _, m := range planned {
+		generated := *m
+		generated.Comment = m.Doc()
+		generated.Name = generated.Alias
+		generated.Builder = builder
+		generated.FanOut = nil
+		recv := builder.Instance()
+		if m.Pointer || builder.Pointer {
+			recv = types.NewPointer(recv)
+		}
+		generated.Recv.Type = recv
+		switch {
+		case m.IsChainingRef():
+			builder.RenderChainMethod(file, *m)
+			generated.Results = []MethodParam{
+				{
+					Type: recv,
+				},
+			}
+			if builder.IsFallible(*m) {
+				generated.Results = append(generated.Results, MethodParam{Type: errorType})
+			}
+		case m.IsFinalizer():
+			builder.RenderFinalizer(file, *m)
+			if builder.StickyError != nil && !endsWithError(m.Results) {
+				generated.Results = append(generated.Results[:len(generated.Results):len(generated.Results)], MethodParam{Type: errorType})
+			}
+		}
+		builder.GeneratedMethods = append(builder.GeneratedMethods, generated)
+	}
+	if c.opts.API || builder.API {
+		builder.RenderAPI(file)
+	}
+	builder.checkStages()
+	builder.RenderStages(file)
+
 	return nil
 }
 
-func (c Chaingen) Generate() error {
+// GenerateFiles loads source code and renders generated files without writing them.
+// Resulting map contains formatted file contents keyed by destination path
+func (c Chaingen) GenerateFiles() (map[string][]byte, error) {
+	gen, err := c.generate()
+	if err != nil {
+		return nil, err
+	}
+	return gen.files, nil
+}
+
+// generation is a result of code generation
+type generation struct {
+	files   map[string][]byte
+	orphans []string
+	// dirs are directories of loaded packages and packages of child builders
+	dirs map[string]bool
+	// deps are directories of non-standard packages imported by loaded packages, directly or indirectly
+	deps map[string]bool
+	// builders are names of builder types rendered by directory
+	builders map[string]map[string]bool
+	// discovered are directories where builders were discovered rather than selected by type names
+	discovered map[string]bool
+}
+
+// isOrphan reports whether the generated file belongs to builders of this generation
+func (g *generation) isOrphan(path string) bool {
+	names, ok := generatedBuilders(path)
+	if !ok {
+		return false
+	}
+	dir := filepath.Dir(path)
+	if g.discovered[dir] {
+		return true
+	}
+	if len(names) == 0 {
+		return false
+	}
+	for _, name := range names {
+		if !g.builders[dir][name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *generation) addPackage(pkg *packages.Package) {
+	for _, file := range pkg.GoFiles {
+		g.dirs[filepath.Dir(file)] = true
+	}
+}
+
+// generate renders generated files and looks up orphaned ones:
+// previously generated files that were not produced by this run
+func (c Chaingen) generate() (*generation, error) {
 	if c.opts.Src == "" {
-		return fmt.Errorf("source dir is not set")
+		return nil, fmt.Errorf("source dir is not set")
 	}
 	if c.opts.FileSuffix == "" {
-		return fmt.Errorf("file suffix is not set")
+		return nil, fmt.Errorf("file suffix is not set")
+	}
+	if c.opts.Conflict != "" {
+		if _, err := ParseConflictStrategy(string(c.opts.Conflict)); err != nil {
+			return nil, err
+		}
 	}
 	pkgs, err := packages.Load(&packages.Config{
 		Dir:        c.opts.Src,
-		Mode:       packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports | packages.NeedModule,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports | packages.NeedModule,
 		BuildFlags: []string{"-tags=" + c.opts.BuildTag},
-	})
+	}, c.opts.Patterns...)
 	if err != nil {
-		return fmt.Errorf("error loading Go packages from %s: %w", c.opts.Src, err)
+		return nil, fmt.Errorf("error loading Go packages from %s: %w", c.location(), err)
+	}
+	gen := &generation{
+		files:      map[string][]byte{},
+		dirs:       map[string]bool{},
+		deps:       map[string]bool{},
+		builders:   map[string]map[string]bool{},
+		discovered: map[string]bool{},
+	}
+	for _, p := range pkgs {
+		gen.addPackage(p)
 	}
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		// Standard library packages don't belong to modules
+		if p.Module == nil {
+			return
+		}
+		for _, file := range p.GoFiles {
+			gen.deps[filepath.Dir(file)] = true
+		}
+	})
 
 	var errors []string
 	for _, p := range pkgs {
@@ -700,60 +1146,315 @@ func (c Chaingen) Generate() error {
 		}
 	}
 	if len(errors) > 0 {
-		return fmt.Errorf("errors occurred loading source code:\n%s\n", strings.Join(errors, "\n"))
+		return gen, fmt.Errorf("errors occurred loading source code:\n%s\n", strings.Join(errors, "\n"))
 	}
 
-	found := make(map[*types.Named]*packages.Package)
+	groups, err := c.packageGroups(pkgs)
+	if err != nil {
+		return gen, err
+	}
+	// processed are directories of source files that were rendered
+	processed := make(map[string]bool)
+	found := 0
+	for _, group := range groups {
+		n, err := group.generator.generatePackages(gen, group.pkgs, processed)
+		if err != nil {
+			return gen, err
+		}
+		found += n
+	}
+	if found == 0 {
+		if c.opts.TypeName == "" {
+			return gen, fmt.Errorf("unable to discover builder types in %s", c.location())
+		}
+		return gen, fmt.Errorf("unable to find builder type %q in %s", c.opts.TypeName, c.location())
+	}
+	// Generated files of processed directories that were not produced by this run, although their builders
+	// belong to it: builders were moved to other files or removed along with their source files.
+	// Files of builders selected by other runs are kept
+	for dir := range processed {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return gen, err
+		}
+		for _, dest := range matches {
+			if _, ok := gen.files[dest]; ok {
+				continue
+			}
+			if gen.isOrphan(dest) {
+				gen.orphans = append(gen.orphans, dest)
+			}
+		}
+	}
+	sort.Strings(gen.orphans)
+	return gen, nil
+}
 
-	names := strings.Split(c.opts.TypeName, ",")
+// packageGroup is a set of packages sharing the same options
+type packageGroup struct {
+	generator Chaingen
+	pkgs      []*packages.Package
+}
+
+// packageGroups groups packages by options resolved from the config. All packages share the same options
+// if there is no config
+func (c Chaingen) packageGroups(pkgs []*packages.Package) ([]packageGroup, error) {
+	if c.config == nil {
+		return []packageGroup{{generator: c, pkgs: pkgs}}, nil
+	}
+	var groups []packageGroup
 	for _, p := range pkgs {
-		for _, name := range names {
+		dir := c.opts.Src
+		if len(p.GoFiles) > 0 {
+			dir = filepath.Dir(p.GoFiles[0])
+		}
+		opts, err := c.PackageOptions(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error applying config to package %s: %w", p.PkgPath, err)
+		}
+		i := 0
+		for i < len(groups) && !reflect.DeepEqual(groups[i].generator.opts, opts) {
+			i++
+		}
+		if i == len(groups) {
+			groups = append(groups, packageGroup{generator: Chaingen{opts: opts}})
+		}
+		groups[i].pkgs = append(groups[i].pkgs, p)
+	}
+	return groups, nil
+}
+
+// generatePackages renders builders of the packages and returns the number of root builders.
+// Directories of rendered source files are added to processed
+func (c Chaingen) generatePackages(gen *generation, pkgs []*packages.Package, processed map[string]bool) (int, error) {
+	type root struct {
+		typ *types.Named
+		pkg *packages.Package
+	}
+	var found []root
+	seen := make(map[*types.Named]bool)
+
+	if c.opts.TypeName == "" {
+		for _, p := range pkgs {
+			for _, typ := range c.discoverBuilders(p) {
+				seen[typ] = true
+				found = append(found, root{typ: typ, pkg: p})
+			}
+		}
+	}
+	names := strings.Split(c.opts.TypeName, ",")
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		for _, p := range pkgs {
 			typ := builderType(objToType(p.Types.Scope().Lookup(name)))
-			if typ != nil {
-				found[typ] = p
+			if typ != nil && !seen[typ] {
+				seen[typ] = true
+				found = append(found, root{typ: typ, pkg: p})
 			}
 		}
 	}
 	if len(found) == 0 {
-		return fmt.Errorf("unable to find builder type %q in %s", c.opts.TypeName, c.opts.Src)
+		return 0, nil
+	}
+	if c.opts.TypeName == "" {
+		for _, p := range pkgs {
+			for _, file := range p.GoFiles {
+				gen.discovered[filepath.Dir(file)] = true
+			}
+		}
 	}
 
 	builders := map[*types.Named]*Builder{}
-	for typ, pkg := range found {
-		err := c.NewBuilder(builders, pkg, typ)
+	for _, r := range found {
+		err := c.NewBuilder(builders, r.pkg, r.typ)
 		if err != nil {
-			return fmt.Errorf("error creating builder: %w", err)
+			return 0, fmt.Errorf("error creating builder: %w", err)
 		}
 	}
+	// Nested builders are rendered along with their parents only in recursive mode
+	if c.opts.TypeName == "" && c.opts.Recursive {
+		roots := make([]*Builder, 0, len(found))
+		for _, r := range found {
+			roots = append(roots, builders[r.typ])
+		}
+		demoteNested(roots)
+	}
+	for _, builder := range builders {
+		gen.addPackage(builder.Package)
+	}
+	var errors []string
+	for _, builder := range sortedBuilders(builders) {
+		for _, err := range builder.Errors {
+			errors = append(errors, err.Error())
+		}
+	}
+	if len(errors) > 0 {
+		return 0, fmt.Errorf("invalid annotations:\n%s", strings.Join(errors, "\n"))
+	}
 
 	files, err := c.Render(builders)
 	if err != nil {
-		return fmt.Errorf("error generating code: %w", err)
+		return 0, fmt.Errorf("error generating code: %w", err)
 	}
 	for _, file := range files {
+		src := file.Path
+		dest := src[:len(src)-3] + c.opts.FileSuffix
+		dir := filepath.Dir(src)
+		processed[dir] = true
 		if file.Body.Len() == 0 {
 			continue
 		}
+		if gen.builders[dir] == nil {
+			gen.builders[dir] = map[string]bool{}
+		}
+		for _, name := range file.builderNames() {
+			gen.builders[dir][name] = true
+		}
 		buf := bytes.Buffer{}
 		err = file.Render(&buf)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		src := file.Path
-		dest := src[:len(src)-3] + c.opts.FileSuffix
 		formatted, err := format.Source(buf.Bytes())
 		if err != nil {
 			log.Printf("error formatting file: %s", err.Error())
 			formatted = buf.Bytes()
 		}
-		err = os.WriteFile(dest, formatted, 0755)
+		// Builders of other packages are rendered along with their parents in recursive mode
+		if existing, ok := gen.files[dest]; ok && !bytes.Equal(existing, formatted) {
+			return 0, fmt.Errorf("%s is generated differently by packages with different options", dest)
+		}
+		gen.files[dest] = formatted
+	}
+	return len(found), nil
+}
+
+// isGenerated reports whether the file exists and carries chaingen header
+func isGenerated(path string) bool {
+	_, ok := generatedBuilders(path)
+	return ok
+}
+
+// generatedBuilders returns builder types listed in the header of the generated file.
+// False is returned if the file doesn't exist or doesn't carry chaingen header
+func generatedBuilders(path string) ([]string, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	if !bytes.Contains(content, []byte("// "+generatedPrefix+"\n")) {
+		return nil, false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if names, ok := strings.CutPrefix(line, buildersPrefix); ok {
+			return strings.Split(names, ","), true
+		}
+	}
+	return nil, true
+}
+
+// Generate generates code and writes it to the disk.
+// In check and diff modes no files are written
+func (c Chaingen) Generate() error {
+	_, err := c.run()
+	return err
+}
+
+// run generates code and writes it to the disk. Source directories are returned even if generation fails,
+// unless source code could not be loaded at all
+func (c Chaingen) run() (map[string]bool, error) {
+	start := time.Now()
+	cachePath := ""
+	// Check and diff modes compare freshly generated code with files on the disk
+	if c.opts.Cache && !c.opts.Check && !c.opts.Diff {
+		cachePath = c.cachePath()
+	}
+	if cachePath != "" {
+		if dirs, ok := c.cached(cachePath); ok {
+			log.Printf("generated files are up to date")
+			return dirs, nil
+		}
+	}
+	gen, err := c.generate()
+	if err != nil {
+		if gen != nil {
+			return gen.dirs, err
+		}
+		return nil, err
+	}
+	if cachePath != "" {
+		err = c.storeCache(cachePath, gen, start)
 		if err != nil {
-			return err
+			log.Printf("warning: error writing cache: %s", err.Error())
 		}
+	}
+	files, orphans := gen.files, gen.orphans
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var stale []string
+	for _, dest := range paths {
+		formatted := files[dest]
 		rel, _ := filepath.Rel(c.opts.Src, dest)
+		if c.opts.Check || c.opts.Diff {
+			existing, err := os.ReadFile(dest)
+			missing := os.IsNotExist(err)
+			if err != nil && !missing {
+				return gen.dirs, err
+			}
+			if !missing && bytes.Equal(existing, formatted) {
+				continue
+			}
+			if c.opts.Diff {
+				oldName := "a/" + filepath.ToSlash(rel)
+				if missing {
+					oldName = "/dev/null"
+				}
+				fmt.Print(unifiedDiff(oldName, "b/"+filepath.ToSlash(rel), existing, formatted))
+			}
+			if missing {
+				stale = append(stale, rel+" (missing)")
+			} else {
+				stale = append(stale, rel+" (stale)")
+			}
+			continue
+		}
+		err = os.WriteFile(dest, formatted, 0755)
+		if err != nil {
+			return gen.dirs, err
+		}
 		log.Printf("generated file: %s", rel)
 	}
-	return nil
+	if c.opts.RemoveOrphans {
+		sort.Strings(orphans)
+		for _, dest := range orphans {
+			rel, _ := filepath.Rel(c.opts.Src, dest)
+			if c.opts.Check || c.opts.Diff {
+				if c.opts.Diff {
+					existing, err := os.ReadFile(dest)
+					if err != nil {
+						return gen.dirs, err
+					}
+					fmt.Print(unifiedDiff("a/"+filepath.ToSlash(rel), "/dev/null", existing, nil))
+				}
+				stale = append(stale, rel+" (orphaned)")
+				continue
+			}
+			err = os.Remove(dest)
+			if err != nil {
+				return gen.dirs, err
+			}
+			log.Printf("removed orphaned file: %s", rel)
+		}
+	}
+	if len(stale) > 0 && c.opts.Check {
+		return gen.dirs, fmt.Errorf("generated files are out of date:\n%s", strings.Join(stale, "\n"))
+	}
+	return gen.dirs, nil
 }
 
 type BuilderSpec struct {
@@ -762,8 +1463,13 @@ type BuilderSpec struct {
 }
 
 func (c Chaingen) NewBuilder(builders map[*types.Named]*Builder, pkg *packages.Package, n *types.Named) error {
-	_, err := c.newBuilder(builders, pkg, n, 0)
-	return err
+	builder, err := c.newBuilder(builders, pkg, n, 0)
+	if err != nil {
+		return err
+	}
+	// The builder could be discovered as a child of another root builder before
+	builder.Depth = 0
+	return nil
 }
 
 func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.Package, typ *types.Named, depth int) (*Builder, error) {
@@ -803,28 +1509,42 @@ func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.P
 		}
 		m := NewMethod(builder, fun, sig)
 		builder.Methods = append(builder.Methods, m)
+		if isPointer(sig.Recv().Type()) {
+			builder.Pointer = true
+		}
 	}
+	sortMethods(pkg.Fset, builder.Methods)
 	// Look up builder comment-based annotations
+	builder.Annotations = c.typeAnnotations(pkg, builder.Type.Obj())
 
-	typePos := pkg.Fset.Position(builder.Type.Obj().Pos())
-	for _, file := range pkg.Syntax {
-		for _, cg := range file.Comments {
-			commentPos := pkg.Fset.Position(cg.End())
-			if commentPos.Filename == builder.FilePath && commentPos.Line == typePos.Line-1 {
-				for _, comment := range cg.List {
-					annotation := strings.Trim(strings.TrimLeft(comment.Text, "/"), " ")
-					tag, ok := reflect.StructTag(annotation).Lookup(c.opts.StructTag)
-					if ok {
-						builder.Annotations = append(builder.Annotations, tag)
-					}
-				}
-			}
+	// Annotations set by configuration
+	for _, name := range []string{builder.Type.Obj().Name(), builder.PkgPath + "." + builder.Type.Obj().Name()} {
+		if annotation, ok := c.opts.TypeAnnotations[name]; ok {
+			builder.Annotations = append(builder.Annotations, Annotation{
+				Value: annotation,
+			})
 		}
 	}
 
 	for _, annotation := range builder.Annotations {
-		if strings.HasPrefix(annotation, "ext(") {
-			methodName := annotation[4:strings.Index(annotation, ")")]
+		modifiers, errs := c.parseTypeAnnotation(pkg.Fset, annotation)
+		for _, err := range errs {
+			if !annotation.Pos.IsValid() {
+				err = fmt.Errorf("%s: annotation %q set by config: %w", builder.Type.Obj().Name(), annotation.Value, err)
+			}
+			builder.Errors = append(builder.Errors, err)
+		}
+		for _, modifier := range modifiers {
+			switch modifier.Kind {
+			case ModifierAPI:
+				builder.API = true
+			case ModifierStage:
+				builder.StageModifiers = append(builder.StageModifiers, modifier)
+			}
+			if modifier.Kind != ModifierExt {
+				continue
+			}
+			methodName := modifier.Selector
 			for _, method := range builder.Methods {
 				if method.Name != methodName {
 					continue
@@ -845,19 +1565,25 @@ func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.P
 				if childPkg == nil {
 					continue
 				}
-				child, err := c.newBuilder(builders, childPkg, typ, depth+1)
+				child, err := c.newBuilder(builders, childPkg, typ.Origin(), depth+1)
 				if err != nil {
 					return nil, fmt.Errorf("error creating external builder %s: %w", methodName, err)
 				}
-				var fieldAnnotation string
-				parts := strings.Split(annotation, "=")
-				if len(parts) == 2 {
-					fieldAnnotation = parts[1]
+				fieldAnnotation := Annotation{
+					Value: modifier.Value,
+					Pos:   modifier.ValuePos,
 				}
+				fieldModifiers, errs := c.parseFieldAnnotation(pkg.Fset, fieldAnnotation)
+				builder.Errors = append(builder.Errors, errs...)
 				builder.Children = append(builder.Children, &BuilderRef{
 					Name:            methodName + "()",
 					IsMethod:        true,
-					FieldAnnotation: fieldAnnotation,
+					Pos:             modifier.Pos,
+					FieldAnnotation: fieldAnnotation.Value,
+					Modifiers:       fieldModifiers,
+					Conflict:        conflictModifier(fieldModifiers),
+					Pointer:         isPointer(method.Results[0].Type),
+					Type:            typ,
 					Builder:         child,
 				})
 				break
@@ -870,8 +1596,33 @@ func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.P
 
 	for i := 0; i < builder.Struct.NumFields(); i++ {
 		field := builder.Struct.Field(i)
-		fieldAnnotation, _ := reflect.StructTag(builder.Struct.Tag(i)).Lookup(c.opts.StructTag)
+		fieldAnnotation, tagged := reflect.StructTag(builder.Struct.Tag(i)).Lookup(c.opts.StructTag)
+		if tagged && types.Identical(field.Type(), errorType) {
+			modifiers, errs := c.parseErrorFieldAnnotation(pkg.Fset, Annotation{
+				Value: fieldAnnotation,
+				Pos:   tagValuePos(builder.File, field.Pos(), c.opts.StructTag),
+			})
+			builder.Errors = append(builder.Errors, errs...)
+			if err := checkStickyMode(pkg, modifiers); err != nil {
+				builder.Errors = append(builder.Errors, err)
+			}
+			if sticky := stickyError(field.Name(), modifiers); sticky != nil {
+				builder.StickyError = sticky
+			}
+			continue
+		}
 		typ := builderType(field.Type())
+		if tagged && (typ == nil || isSetterAnnotation(fieldAnnotation)) {
+			modifiers, errs := c.parseSetterFieldAnnotation(pkg.Fset, Annotation{
+				Value: fieldAnnotation,
+				Pos:   tagValuePos(builder.File, field.Pos(), c.opts.StructTag),
+			})
+			builder.Errors = append(builder.Errors, errs...)
+			setters, errs := newSetters(pkg.Fset, field, modifiers)
+			builder.Errors = append(builder.Errors, errs...)
+			builder.Setters = append(builder.Setters, setters...)
+			continue
+		}
 		if typ == nil {
 			continue
 		}
@@ -887,13 +1638,23 @@ func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.P
 		if childPkg == nil {
 			continue
 		}
-		child, err := c.newBuilder(builders, childPkg, typ, depth+1)
+		child, err := c.newBuilder(builders, childPkg, typ.Origin(), depth+1)
 		if err != nil {
 			return nil, fmt.Errorf("error creating builder for field %s: %w", field.Name(), err)
 		}
+		modifiers, errs := c.parseFieldAnnotation(pkg.Fset, Annotation{
+			Value: fieldAnnotation,
+			Pos:   tagValuePos(builder.File, field.Pos(), c.opts.StructTag),
+		})
+		builder.Errors = append(builder.Errors, errs...)
 		builder.Children = append(builder.Children, &BuilderRef{
 			Name:            name,
+			Pos:             field.Pos(),
 			FieldAnnotation: fieldAnnotation,
+			Modifiers:       modifiers,
+			Conflict:        conflictModifier(modifiers),
+			Pointer:         isPointer(field.Type()),
+			Type:            typ,
 			Builder:         child,
 		})
 	}
@@ -901,6 +1662,11 @@ func (c Chaingen) newBuilder(builders map[*types.Named]*Builder, pkg *packages.P
 	return builder, nil
 }
 
+func isPointer(typ types.Type) bool {
+	_, ok := typ.(*types.Pointer)
+	return ok
+}
+
 func objToType(obj types.Object) types.Type {
 	if obj == nil {
 		return nil
@@ -0,0 +1,198 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule writes files into a temporary module directory. go.mod is added unless provided
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, ok := files["go.mod"]; !ok {
+		files["go.mod"] = "module example.com/m\n\ngo 1.22\n"
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testOptions returns options matching command line defaults
+func testOptions(dir string) Options {
+	return Options{
+		Src:               dir,
+		FileSuffix:        ".chaingen.go",
+		ErrOnConflict:     true,
+		StructTag:         "chaingen",
+		BuildTag:          "chaingen",
+		RemoveOrphans:     true,
+		ErrOnUnrenderable: true,
+	}
+}
+
+func TestGenerateMovedBuilder(t *testing.T) {
+	child := "package m\n\n" +
+		"type Child struct{ n int }\n\n" +
+		"func (c Child) Set(n int) Child { c.n = n; return c }\n"
+	parent := "package m\n\n" +
+		"type Parent struct{ C Child }\n"
+	dir := writeModule(t, map[string]string{
+		"child.go": child,
+		"p.go":     parent,
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	err := New(opts).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGenerated(filepath.Join(dir, "p.chaingen.go")) {
+		t.Fatal("p.chaingen.go is not generated")
+	}
+
+	// Parent is moved to q.go, p.go is kept
+	err = os.WriteFile(filepath.Join(dir, "p.go"), []byte("package m\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "q.go"), []byte(parent), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = New(opts).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "p.chaingen.go")); !os.IsNotExist(err) {
+		t.Fatal("p.chaingen.go is not removed")
+	}
+	if !isGenerated(filepath.Join(dir, "q.chaingen.go")) {
+		t.Fatal("q.chaingen.go is not generated")
+	}
+}
+
+func TestGenerateSharedPackage(t *testing.T) {
+	child := "package m\n\n" +
+		"type Child struct{ n int }\n\n" +
+		"func (c Child) Set(n int) Child { c.n = n; return c }\n"
+	dir := writeModule(t, map[string]string{
+		"child.go": child,
+		"a.go":     "package m\n\ntype A struct{ C Child }\n",
+		"b.go":     "package m\n\ntype B struct{ C Child }\n",
+	})
+	opts := testOptions(dir)
+	for _, name := range []string{"A", "B"} {
+		opts.TypeName = name
+		err := New(opts).Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.chaingen.go", "b.chaingen.go"} {
+		if !isGenerated(filepath.Join(dir, name)) {
+			t.Fatalf("%s is not kept", name)
+		}
+	}
+	for _, name := range []string{"A", "B"} {
+		opts.TypeName = name
+		opts.Check = true
+		err := New(opts).Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateExcludeGlob(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type Child struct{ n int }\n\n" +
+		"func (c Child) SetA(n int) Child { c.n = n; return c }\n\n" +
+		"func (c Child) SetB(n int) Child { c.n = n; return c }\n\n" +
+		"func (c Child) Reset() Child { c.n = 0; return c }\n\n" +
+		"type Parent struct {\n\tC Child `chaingen:\"*,-Set*\"`\n}\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	files, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(files[filepath.Join(dir, "m.chaingen.go")])
+	if !strings.Contains(content, ") Reset() Parent {") {
+		t.Fatalf("Reset is not generated:\n%s", content)
+	}
+	if strings.Contains(content, ") SetA(") || strings.Contains(content, ") SetB(") {
+		t.Fatalf("excluded methods are generated:\n%s", content)
+	}
+}
+
+func TestGenerateMethodOrder(t *testing.T) {
+	files := map[string]string{
+		"parent.go": "package m\n\ntype Parent struct{ C Child }\n\ntype Child struct{ n int }\n",
+	}
+	// Methods are declared in reverse alphabetical order of their names
+	names := []string{"H", "G", "F", "E", "D", "C", "B", "A"}
+	for i, name := range names {
+		files[fmt.Sprintf("child%d.go", i)] = "package m\n\nfunc (c Child) " + name + "() Child { return c }\n"
+	}
+	dir := writeModule(t, files)
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	var first string
+	for run := 0; run < 20; run++ {
+		generated, err := New(opts).GenerateFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(generated[filepath.Join(dir, "parent.chaingen.go")])
+		if run == 0 {
+			first = content
+			last := -1
+			for _, name := range names {
+				pos := strings.Index(content, ") "+name+"() Parent {")
+				if pos < 0 || pos < last {
+					t.Fatalf("method %s is out of order:\n%s", name, content)
+				}
+				last = pos
+			}
+		} else if content != first {
+			t.Fatalf("output differs between runs:\n%s\n%s", first, content)
+		}
+	}
+}
+
+func TestSortMethods(t *testing.T) {
+	// Files are added to the file set in the reverse order of their names, as concurrent parsing may do
+	fset := token.NewFileSet()
+	var methods []Method
+	for i := 3; i >= 0; i-- {
+		file := fset.AddFile(fmt.Sprintf("child%d.go", i), -1, 100)
+		file.SetLines([]int{0, 50})
+		methods = append(methods,
+			Method{Name: fmt.Sprintf("M%d_2", i), Pos: file.Pos(60)},
+			Method{Name: fmt.Sprintf("M%d_1", i), Pos: file.Pos(10)},
+		)
+	}
+	sortMethods(fset, methods)
+	var names []string
+	for _, m := range methods {
+		names = append(names, m.Name)
+	}
+	expected := "M0_1 M0_2 M1_1 M1_2 M2_1 M2_2 M3_1 M3_2"
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("unexpected order %v, expected %s", names, expected)
+	}
+}
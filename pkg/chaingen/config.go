@@ -0,0 +1,207 @@
+package chaingen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigFileName is a name of the project-wide configuration file
+const ConfigFileName = "chaingen.json"
+
+// Config is a project-wide configuration. Keys of options are named after command line flags
+type Config struct {
+	ConfigOptions
+	// Packages holds per-package overrides keyed by package directory relative to the config file.
+	// Keys ending with "/..." apply to the directory and all its subdirectories
+	Packages map[string]ConfigOptions `json:"packages"`
+	// Types holds annotations that are added to the type comment annotations of builders.
+	// Keys are type names, optionally qualified by package path: github.com/user/pkg.Builder
+	Types map[string]string `json:"types"`
+	// Flags are options set by command line flags. They take precedence over global and package options of the config
+	Flags ConfigOptions `json:"-"`
+	// Dir is a directory of the config file
+	Dir string `json:"-"`
+}
+
+// ConfigOptions are options set by config file. Unset options are nil
+type ConfigOptions struct {
+	TypeName          *string `json:"type"`
+	Recursive         *bool   `json:"recursive"`
+	FileSuffix        *string `json:"file-suffix"`
+	ErrOnConflict     *bool   `json:"err-on-conflict"`
+	Conflict          *string `json:"conflict"`
+	StructTag         *string `json:"struct-tag"`
+	BuildTag          *string `json:"build-tag"`
+	Check             *bool   `json:"check"`
+	Diff              *bool   `json:"diff"`
+	RemoveOrphans     *bool   `json:"remove-orphans"`
+	ErrOnUnrenderable *bool   `json:"err-on-unrenderable"`
+	Strict            *bool   `json:"strict"`
+	API               *bool   `json:"api"`
+	Cache             *bool   `json:"cache"`
+}
+
+// FindConfig looks up config file walking up from the directory to the module root.
+// Empty string is returned if there is no config file
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+	config := &Config{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(config)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	config.Dir, err = filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Apply sets options from global defaults, overrides of the package located at opts.Src, flags and type annotations.
+// More specific package overrides take precedence
+func (c *Config) Apply(opts *Options) error {
+	err := c.ConfigOptions.apply(opts)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(c.Dir, opts.Src)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	var patterns []string
+	for pattern := range c.Packages {
+		if matchPackage(pattern, rel) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	// Less specific patterns are applied first: wildcards before exact matches,
+	// wildcards with shorter prefixes before longer ones
+	sort.Slice(patterns, func(i, j int) bool {
+		wi, di := packageSpecificity(patterns[i])
+		wj, dj := packageSpecificity(patterns[j])
+		if wi != wj {
+			return wi
+		}
+		if di != dj {
+			return di < dj
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		err = c.Packages[pattern].apply(opts)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", pattern, err)
+		}
+	}
+	err = c.Flags.apply(opts)
+	if err != nil {
+		return err
+	}
+	if len(c.Types) > 0 && opts.TypeAnnotations == nil {
+		opts.TypeAnnotations = map[string]string{}
+	}
+	for name, annotation := range c.Types {
+		opts.TypeAnnotations[name] = annotation
+	}
+	return nil
+}
+
+func (o ConfigOptions) apply(opts *Options) error {
+	setString(&opts.TypeName, o.TypeName)
+	setBool(&opts.Recursive, o.Recursive)
+	setString(&opts.FileSuffix, o.FileSuffix)
+	setBool(&opts.ErrOnConflict, o.ErrOnConflict)
+	if o.Conflict != nil {
+		strategy, err := ParseConflictStrategy(*o.Conflict)
+		if err != nil {
+			return err
+		}
+		opts.Conflict = strategy
+	}
+	setString(&opts.StructTag, o.StructTag)
+	setString(&opts.BuildTag, o.BuildTag)
+	setBool(&opts.Check, o.Check)
+	setBool(&opts.Diff, o.Diff)
+	setBool(&opts.RemoveOrphans, o.RemoveOrphans)
+	setBool(&opts.ErrOnUnrenderable, o.ErrOnUnrenderable)
+	if o.Strict != nil {
+		opts.Lenient = !*o.Strict
+	}
+	setBool(&opts.API, o.API)
+	setBool(&opts.Cache, o.Cache)
+	return nil
+}
+
+func setString(dst *string, src *string) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// packageSpecificity reports whether the package pattern is a wildcard and returns depth of its prefix
+func packageSpecificity(pattern string) (bool, int) {
+	pattern = strings.TrimPrefix(pattern, "./")
+	prefix := strings.TrimSuffix(pattern, "...")
+	prefix = strings.TrimSuffix(prefix, "/")
+	depth := 0
+	if prefix != "" && prefix != "." {
+		depth = strings.Count(prefix, "/") + 1
+	}
+	return prefix != pattern, depth
+}
+
+// matchPackage reports whether package directory matches the pattern
+func matchPackage(pattern string, dir string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if pattern == "..." {
+		return true
+	}
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return dir == prefix || strings.HasPrefix(dir, prefix+"/")
+	}
+	if pattern == "" {
+		pattern = "."
+	}
+	return dir == pattern
+}
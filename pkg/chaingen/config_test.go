@@ -0,0 +1,126 @@
+package chaingen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewWithConfigPackagePatterns(t *testing.T) {
+	source := "package %s\n\n" +
+		"type Child struct{ n int }\n\n" +
+		"func (c Child) Set(n int) Child { c.n = n; return c }\n\n" +
+		"type Parent struct {\n\tC Child\n}\n"
+	dir := writeModule(t, map[string]string{
+		"a/a.go":        strings.Replace(source, "%s", "a", 1),
+		"b/b.go":        strings.Replace(source, "%s", "b", 1),
+		"b/nested/n.go": strings.Replace(source, "%s", "nested", 1),
+	})
+	suffix := ".b.go"
+	api := true
+	typeName := "Parent"
+	config := &Config{
+		ConfigOptions: ConfigOptions{TypeName: &typeName},
+		Packages: map[string]ConfigOptions{
+			"b/...":    {FileSuffix: &suffix},
+			"b/nested": {API: &api},
+		},
+		Dir: dir,
+	}
+	opts := testOptions(dir)
+	opts.Patterns = []string{"./..."}
+	c, err := NewWithConfig(opts, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := c.GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for path := range files {
+		rel, _ := filepath.Rel(dir, path)
+		names = append(names, filepath.ToSlash(rel))
+	}
+	expected := map[string]bool{"a/a.chaingen.go": true, "b/b.b.go": true, "b/nested/n.b.go": true}
+	if len(names) != len(expected) {
+		t.Fatalf("expected files %v, got %v", expected, names)
+	}
+	for _, name := range names {
+		if !expected[name] {
+			t.Fatalf("expected files %v, got %v", expected, names)
+		}
+	}
+	if content := string(files[filepath.Join(dir, "b/nested/n.b.go")]); !strings.Contains(content, "type ParentAPI interface") {
+		t.Fatalf("package override is not applied:\n%s", content)
+	}
+	if content := string(files[filepath.Join(dir, "b/b.b.go")]); strings.Contains(content, "type ParentAPI interface") {
+		t.Fatalf("override of the nested package is applied to the parent package:\n%s", content)
+	}
+}
+
+func TestConfigApplyPrecedence(t *testing.T) {
+	global, pkg, flag := ".global.go", ".pkg.go", ".flag.go"
+	strict := false
+	for _, tc := range []struct {
+		name     string
+		src      string
+		flags    ConfigOptions
+		expected string
+	}{
+		{name: "global", src: "a", expected: global},
+		{name: "package", src: "b/c", expected: pkg},
+		{name: "flag", src: "b/c", flags: ConfigOptions{FileSuffix: &flag}, expected: flag},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{
+				ConfigOptions: ConfigOptions{FileSuffix: &global, Strict: &strict},
+				Packages:      map[string]ConfigOptions{"b/...": {FileSuffix: &pkg}},
+				Flags:         tc.flags,
+				Dir:           "/m",
+			}
+			opts := testOptions("/m/" + tc.src)
+			err := config.Apply(&opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.FileSuffix != tc.expected {
+				t.Fatalf("expected file suffix %q, got %q", tc.expected, opts.FileSuffix)
+			}
+			if !opts.Lenient {
+				t.Fatal("expected lenient mode set by config")
+			}
+		})
+	}
+}
+
+func TestConfigApplyPackageOrder(t *testing.T) {
+	suffixes := map[string]string{
+		"...":       ".all.go",
+		"a/...":     ".a.go",
+		"./a/b/...": ".ab.go",
+		"a/b":       ".exact.go",
+	}
+	packages := map[string]ConfigOptions{}
+	for pattern := range suffixes {
+		suffix := suffixes[pattern]
+		packages[pattern] = ConfigOptions{FileSuffix: &suffix}
+	}
+	for src, expected := range map[string]string{
+		"x":     ".all.go",
+		"a":     ".a.go",
+		"a/c":   ".a.go",
+		"a/b":   ".exact.go",
+		"a/b/c": ".ab.go",
+	} {
+		config := &Config{Packages: packages, Dir: "/m"}
+		opts := testOptions("/m/" + src)
+		err := config.Apply(&opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.FileSuffix != expected {
+			t.Fatalf("expected file suffix %q for %s, got %q", expected, src, opts.FileSuffix)
+		}
+	}
+}
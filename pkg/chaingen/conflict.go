@@ -0,0 +1,125 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/types"
+	"log"
+	"strings"
+)
+
+// ConflictStrategy defines how method naming conflicts are resolved
+type ConflictStrategy string
+
+const (
+	// ConflictError fails generation on conflicts between children
+	ConflictError ConflictStrategy = "error"
+	// ConflictParentWins fails generation on conflicts between children, same as ConflictError
+	ConflictParentWins ConflictStrategy = "parent-wins"
+	// ConflictFirstChildWins skips child methods that are generated from preceding children
+	ConflictFirstChildWins ConflictStrategy = "first-child-wins"
+	// ConflictFanOut generates a single chaining method that calls conflicting methods of every child
+	ConflictFanOut ConflictStrategy = "fan-out"
+)
+
+var conflictStrategies = []ConflictStrategy{ConflictError, ConflictParentWins, ConflictFirstChildWins, ConflictFanOut}
+
+// ParseConflictStrategy returns conflict strategy by its name
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	names := make([]string, 0, len(conflictStrategies))
+	for _, strategy := range conflictStrategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+		names = append(names, string(strategy))
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q, expected one of: %s", s, strings.Join(names, ", "))
+}
+
+// conflictStrategy returns strategy that is used for methods of the child builder
+func (c Chaingen) conflictStrategy(child *BuilderRef) ConflictStrategy {
+	if child.Conflict != "" {
+		return child.Conflict
+	}
+	if c.opts.Conflict != "" {
+		return c.opts.Conflict
+	}
+	if c.opts.ErrOnConflict {
+		return ConflictError
+	}
+	return ConflictFirstChildWins
+}
+
+// resolveConflict reports whether method of the child builder should be generated in the parent builder.
+// Methods declared by the parent builder are skipped regardless of the strategy. Conflicts between children
+// are resolved by the strategy of the later child. Every resolution is reported in Builder.Conflicts
+func (c Chaingen) resolveConflict(builder *Builder, child *BuilderRef, m Method) (bool, error) {
+	strategy := c.conflictStrategy(child)
+	name := builder.Type.Obj().Name() + "." + m.Alias
+	diagnostic := func(format string, args ...any) Diagnostic {
+		return Diagnostic{
+			Pos:     builder.Package.Fset.Position(child.Pos),
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+	report := func(format string, args ...any) {
+		d := diagnostic(format, args...)
+		builder.Conflicts = append(builder.Conflicts, d)
+		log.Printf("%s", d.Error())
+	}
+	for _, own := range builder.Methods {
+		if own.Name != m.Alias {
+			continue
+		}
+		report("conflict %s: kept %s declared by parent, skipped %s", name, own.String(), m.String())
+		return false, nil
+	}
+	conflict, ok := builder.MethodNames[m.Alias]
+	if !ok {
+		return true, nil
+	}
+	if strategy == ConflictFanOut {
+		if !conflict.IsChainingRef() || !m.IsChainingRef() {
+			return false, diagnostic("method naming conflict for %s: unable to fan out %s and %s, only chaining methods can be fanned out", name, conflict.String(), m.String())
+		}
+		if !sameSignature(*conflict, m) {
+			return false, diagnostic("method naming conflict for %s: unable to fan out %s and %s, signatures are different", name, conflict.String(), m.String())
+		}
+		for _, target := range append([]Method{*conflict}, conflict.FanOut...) {
+			if target.Ref == m.Ref {
+				return false, diagnostic("method naming conflict for %s: %s and %s", name, target.String(), m.String())
+			}
+		}
+		conflict.FanOut = append(conflict.FanOut, m)
+		report("conflict %s (%s): calling %s along with %s", name, strategy, m.String(), conflict.String())
+		return false, nil
+	}
+	if strategy != ConflictFirstChildWins {
+		return false, diagnostic("method naming conflict for %s: %s and %s", name, conflict.String(), m.String())
+	}
+	report("conflict %s (%s): kept %s, skipped %s", name, strategy, conflict.String(), m.String())
+	return false, nil
+}
+
+// sameSignature reports whether methods accept identical parameters and are both either fallible or not
+func sameSignature(a Method, b Method) bool {
+	if len(a.Params) != len(b.Params) || a.Variadic != b.Variadic || a.IsFallible() != b.IsFallible() {
+		return false
+	}
+	for i := range a.Params {
+		if !types.Identical(a.Params[i].Type, b.Params[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// conflictModifier returns conflict strategy set by annotation modifiers
+func conflictModifier(modifiers []Modifier) ConflictStrategy {
+	var strategy ConflictStrategy
+	for _, modifier := range modifiers {
+		if modifier.Kind == ModifierConflict {
+			strategy = ConflictStrategy(modifier.Selector)
+		}
+	}
+	return strategy
+}
@@ -0,0 +1,215 @@
+package chaingen
+
+import (
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestConflictStrategies(t *testing.T) {
+	source := "package m\n\n" +
+		"type A struct{ n int }\n\n" +
+		"func (a A) Set(n int) A { a.n = n; return a }\n\n" +
+		"func (a A) Get() int { return a.n }\n\n" +
+		"type B struct{ n int }\n\n" +
+		"func (b B) Set(n int) B { b.n = n; return b }\n\n" +
+		"func (b B) Get() int { return b.n }\n\n" +
+		"type Parent struct {\n\tA A\n\tB B\n}\n\n" +
+		"func (p Parent) Get() int { return p.A.n + p.B.n }\n"
+	for _, tc := range []struct {
+		strategy ConflictStrategy
+		err      string
+		contains []string
+		excludes []string
+	}{
+		{
+			strategy: ConflictError,
+			err:      "m.go:17:2: method naming conflict for Parent.Set: A.Set and B.Set",
+		},
+		{
+			strategy: ConflictParentWins,
+			err:      "m.go:17:2: method naming conflict for Parent.Set: A.Set and B.Set",
+		},
+		{
+			strategy: ConflictFirstChildWins,
+			contains: []string{"p.A = p.A.Set(n)"},
+			excludes: []string{"p.B.Set(n)", "p.A.Get()", "p.B.Get()"},
+		},
+		{
+			strategy: ConflictFanOut,
+			contains: []string{"p.A = p.A.Set(n)\n\tp.B = p.B.Set(n)"},
+			excludes: []string{"p.A.Get()", "p.B.Get()"},
+		},
+	} {
+		t.Run(string(tc.strategy), func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"m.go": source})
+			opts := testOptions(dir)
+			opts.TypeName = "Parent"
+			opts.Conflict = tc.strategy
+			files, err := New(opts).GenerateFiles()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(files[filepath.Join(dir, "m.chaingen.go")])
+			for _, s := range tc.contains {
+				if !strings.Contains(content, s) {
+					t.Fatalf("generated code doesn't contain %q:\n%s", s, content)
+				}
+			}
+			for _, s := range tc.excludes {
+				if strings.Contains(content, s) {
+					t.Fatalf("generated code contains %q:\n%s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestConflictFieldStrategy(t *testing.T) {
+	source := func(a, b string) string {
+		return "package m\n\n" +
+			"type A struct{ n int }\n\n" +
+			"func (a A) Set(n int) A { a.n = n; return a }\n\n" +
+			"type B struct{ n int }\n\n" +
+			"func (b B) Set(n int) B { b.n = n; return b }\n\n" +
+			"type Parent struct {\n\tA A `chaingen:\"" + a + "\"`\n\tB B `chaingen:\"" + b + "\"`\n}\n"
+	}
+	for _, tc := range []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{
+			name:   "later child",
+			source: source("*", "conflict(first-child-wins)"),
+		},
+		{
+			name:   "earlier child",
+			source: source("conflict(first-child-wins)", "*"),
+			err:    "m.go:13:2: method naming conflict for Parent.Set: A.Set and B.Set",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"m.go": tc.source})
+			opts := testOptions(dir)
+			opts.TypeName = "Parent"
+			opts.Conflict = ConflictError
+			_, err := New(opts).GenerateFiles()
+			if tc.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestConflictReports(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type A struct{ n int }\n\n" +
+		"func (a A) Set(n int) A { a.n = n; return a }\n\n" +
+		"func (a A) Get() int { return a.n }\n\n" +
+		"type B struct{ n int }\n\n" +
+		"func (b B) Set(n int) B { b.n = n; return b }\n\n" +
+		"type Parent struct {\n\tA A\n\tB B\n}\n\n" +
+		"func (p Parent) Get() int { return p.A.n }\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	opts.Conflict = ConflictFirstChildWins
+	c := New(opts)
+	pkgs, err := packages.Load(&packages.Config{
+		Dir:  dir,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+	}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := builderType(objToType(pkgs[0].Types.Scope().Lookup("Parent")))
+	builders := map[*types.Named]*Builder{}
+	err = c.NewBuilder(builders, pkgs[0], typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := builders[typ]
+	_, err = c.Render(builders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reports []string
+	for _, d := range parent.Conflicts {
+		reports = append(reports, filepath.Base(d.Error()))
+	}
+	expected := []string{
+		"m.go:14:2: conflict Parent.Get: kept Parent.Get declared by parent, skipped A.Get",
+		"m.go:15:2: conflict Parent.Set (first-child-wins): kept A.Set, skipped B.Set",
+	}
+	if strings.Join(reports, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected reports:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(reports, "\n"))
+	}
+}
+
+func TestConflictOfSkippedMethod(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"c/c.go": "package c\n\n" +
+			"type hidden int\n\n" +
+			"type C struct{ v hidden }\n\n" +
+			"func (c C) Set(v hidden) C { c.v = v; return c }\n",
+		"m.go": "package m\n\n" +
+			"import \"example.com/m/c\"\n\n" +
+			"type Parent struct{ C c.C }\n\n" +
+			"func (p Parent) Set(n int) Parent { return p }\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	opts.Conflict = ConflictError
+	opts.ErrOnUnrenderable = false
+	_, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatalf("unrenderable method takes part in conflict resolution: %v", err)
+	}
+}
+
+func TestConflictFanOutFallible(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type A struct{ n int }\n\n" +
+		"func (a A) Set(n int) (A, error) { a.n = n; return a, nil }\n\n" +
+		"type B struct{ n int }\n\n" +
+		"func (b B) Set(n int) (B, error) { b.n = n; return b, nil }\n\n" +
+		"type Parent struct {\n" +
+		"\tA A `chaingen:\"conflict(fan-out),pre(Set)=println(1)\"`\n" +
+		"\tB B `chaingen:\"conflict(fan-out),post(Set)=println(2)\"`\n" +
+		"}\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	files, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(files[filepath.Join(dir, "m.chaingen.go")])
+	expected := "func (p Parent) Set(n int) (Parent, error) {\n" +
+		"\tprintln(1)\n" +
+		"\tchild, err := p.A.Set(n)\n" +
+		"\tif err != nil {\n\t\treturn p, err\n\t}\n" +
+		"\tchild1, err := p.B.Set(n)\n" +
+		"\tif err != nil {\n\t\treturn p, err\n\t}\n" +
+		"\tp.A = child\n" +
+		"\tp.B = child1\n" +
+		"\tprintln(2)\n" +
+		"\treturn p, nil\n" +
+		"}"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("generated code doesn't contain %q:\n%s", expected, content)
+	}
+}
@@ -0,0 +1,117 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// Diagnostic is a problem found in the source code
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	if !d.Pos.IsValid() {
+		return d.Message
+	}
+	return d.Pos.String() + ": " + d.Message
+}
+
+// checkRenderable returns Diagnostic if the method refers types that can't be used in the builder package
+func (b *Builder) checkRenderable(method Method) error {
+	var params []MethodParam
+	params = append(params, method.Params...)
+	params = append(params, method.Results...)
+	for _, param := range params {
+		typ := unrenderableType(param.Type, b.PkgPath)
+		if typ == nil {
+			continue
+		}
+		return Diagnostic{
+			Pos: method.Builder.Package.Fset.Position(method.Pos),
+			Message: fmt.Sprintf("unable to render method %s.%s from %s: type %s is not accessible from package %s",
+				b.Type.Obj().Name(), method.Alias, method.String(), typ.String(), b.PkgPath),
+		}
+	}
+	return nil
+}
+
+// unrenderableType returns the part of the type that can't be referenced from the package
+func unrenderableType(typ types.Type, pkgPath string) types.Type {
+	switch t := typ.(type) {
+	case *types.Basic:
+		if t.Kind() == types.Invalid {
+			return t
+		}
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil {
+			if obj.Pkg().Path() != pkgPath && !obj.Exported() {
+				return t
+			}
+			// Types declared inside function bodies
+			if obj.Parent() != nil && obj.Parent() != obj.Pkg().Scope() {
+				return t
+			}
+		}
+		if args := t.TypeArgs(); args != nil {
+			for i := 0; i < args.Len(); i++ {
+				if u := unrenderableType(args.At(i), pkgPath); u != nil {
+					return u
+				}
+			}
+		}
+	case *types.Pointer:
+		return unrenderableType(t.Elem(), pkgPath)
+	case *types.Slice:
+		return unrenderableType(t.Elem(), pkgPath)
+	case *types.Array:
+		return unrenderableType(t.Elem(), pkgPath)
+	case *types.Chan:
+		return unrenderableType(t.Elem(), pkgPath)
+	case *types.Map:
+		if u := unrenderableType(t.Key(), pkgPath); u != nil {
+			return u
+		}
+		return unrenderableType(t.Elem(), pkgPath)
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if u := unrenderableType(t.At(i).Type(), pkgPath); u != nil {
+				return u
+			}
+		}
+	case *types.Signature:
+		if u := unrenderableType(t.Params(), pkgPath); u != nil {
+			return u
+		}
+		return unrenderableType(t.Results(), pkgPath)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			if !field.Exported() && field.Pkg() != nil && field.Pkg().Path() != pkgPath {
+				return t
+			}
+			if u := unrenderableType(field.Type(), pkgPath); u != nil {
+				return u
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			method := t.ExplicitMethod(i)
+			if !method.Exported() && method.Pkg() != nil && method.Pkg().Path() != pkgPath {
+				return t
+			}
+			if u := unrenderableType(method.Type(), pkgPath); u != nil {
+				return u
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if u := unrenderableType(t.EmbeddedType(i), pkgPath); u != nil {
+				return u
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package chaingen
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// unifiedDiff returns unified diff between old and new contents.
+// Empty string is returned if contents are equal
+func unifiedDiff(oldName, newName string, old, new []byte) string {
+	ops := diffLines(splitLines(string(old)), splitLines(string(new)))
+	// oldLines[i] and newLines[i] hold amount of old and new lines preceding ops[i]
+	oldLines := make([]int, len(ops)+1)
+	newLines := make([]int, len(ops)+1)
+	changed := false
+	for i, op := range ops {
+		oldLines[i+1] = oldLines[i]
+		newLines[i+1] = newLines[i]
+		if op.Kind != '+' {
+			oldLines[i+1]++
+		}
+		if op.Kind != '-' {
+			newLines[i+1]++
+		}
+		if op.Kind != ' ' {
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	out := strings.Builder{}
+	out.WriteString("--- " + oldName + "\n")
+	out.WriteString("+++ " + newName + "\n")
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+		out.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(oldLines[start], oldLines[end]),
+			hunkRange(newLines[start], newLines[end]),
+		))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.Kind)
+			out.WriteString(op.Line)
+			if !strings.HasSuffix(op.Line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes line-based edit script using longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, diffOp{Kind: ' ', Line: a[0]})
+		a, b = a[1:], b[1:]
+	}
+	end := len(a)
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	suffix := a[len(a):end]
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{Kind: ' ', Line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Kind: '-', Line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{Kind: '+', Line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{Kind: '-', Line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{Kind: '+', Line: b[j]})
+	}
+	for _, line := range suffix {
+		ops = append(ops, diffOp{Kind: ' ', Line: line})
+	}
+	return ops
+}
@@ -0,0 +1,86 @@
+package chaingen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns lines 1..n, lines listed in changed are prefixed with X
+func numberedLines(n int, changed ...int) string {
+	s := strings.Builder{}
+	for i := 1; i <= n; i++ {
+		prefix := ""
+		for _, c := range changed {
+			if c == i {
+				prefix = "X"
+			}
+		}
+		s.WriteString(fmt.Sprintf("%s%d\n", prefix, i))
+	}
+	return s.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new string
+		diff     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			diff: "",
+		},
+		{
+			name: "insert only",
+			old:  "a\nb\n",
+			new:  "a\nx\nb\n",
+			diff: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n a\n+x\n b\n",
+		},
+		{
+			name: "delete only",
+			old:  "a\nx\nb\n",
+			new:  "a\nb\n",
+			diff: "--- a\n+++ b\n@@ -1,3 +1,2 @@\n a\n-x\n b\n",
+		},
+		{
+			name: "empty old",
+			old:  "",
+			new:  "a\nb\n",
+			diff: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "empty new",
+			old:  "a\n",
+			new:  "",
+			diff: "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			diff: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "changes 2*diffContext lines apart are merged",
+			old:  numberedLines(15),
+			new:  numberedLines(15, 2, 9),
+			diff: "--- a\n+++ b\n@@ -1,12 +1,12 @@\n 1\n-2\n+X2\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+X9\n 10\n 11\n 12\n",
+		},
+		{
+			name: "changes 2*diffContext+1 lines apart are split",
+			old:  numberedLines(15),
+			new:  numberedLines(15, 2, 10),
+			diff: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+X2\n 3\n 4\n 5\n@@ -7,7 +7,7 @@\n 7\n 8\n 9\n-10\n+X10\n 11\n 12\n 13\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := unifiedDiff("a", "b", []byte(tc.old), []byte(tc.new))
+			if diff != tc.diff {
+				t.Fatalf("unexpected diff:\n%s\nexpected:\n%s", diff, tc.diff)
+			}
+		})
+	}
+}
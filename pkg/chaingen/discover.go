@@ -0,0 +1,143 @@
+package chaingen
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// location returns description of the loaded source code for error messages
+func (c Chaingen) location() string {
+	if len(c.opts.Patterns) == 0 {
+		return c.opts.Src
+	}
+	return strings.Join(c.opts.Patterns, " ") + " in " + c.opts.Src
+}
+
+// typeAnnotations returns annotations from the comment preceding type declaration
+func (c Chaingen) typeAnnotations(pkg *packages.Package, obj types.Object) []Annotation {
+	var annotations []Annotation
+	typePos := pkg.Fset.Position(obj.Pos())
+	for _, file := range pkg.Syntax {
+		for _, cg := range file.Comments {
+			commentPos := pkg.Fset.Position(cg.End())
+			if commentPos.Filename == typePos.Filename && commentPos.Line == typePos.Line-1 {
+				for _, comment := range cg.List {
+					annotation := strings.Trim(strings.TrimLeft(comment.Text, "/"), " ")
+					tag, ok := reflect.StructTag(annotation).Lookup(c.opts.StructTag)
+					if ok {
+						annotations = append(annotations, Annotation{
+							Value: tag,
+							Pos:   valuePos(comment.Pos(), comment.Text, c.opts.StructTag),
+						})
+					}
+				}
+			}
+		}
+	}
+	return annotations
+}
+
+// discoverBuilders returns struct types of the package that are marked by type comment annotation
+// or have fields of builder types declared in the same module
+func (c Chaingen) discoverBuilders(pkg *packages.Package) []*types.Named {
+	var found []*types.Named
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+		typ, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		s, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		if len(c.typeAnnotations(pkg, obj)) > 0 || hasBuilderFields(pkg, s) {
+			found = append(found, typ)
+		}
+	}
+	return found
+}
+
+// hasBuilderFields reports whether the struct has fields of builder types declared in the module of the package
+func hasBuilderFields(pkg *packages.Package, s *types.Struct) bool {
+	for i := 0; i < s.NumFields(); i++ {
+		typ := builderType(s.Field(i).Type())
+		if typ == nil || typ.Obj().Pkg() == nil {
+			continue
+		}
+		path := typ.Obj().Pkg().Path()
+		if pkg.Module == nil && path != pkg.PkgPath {
+			continue
+		}
+		if pkg.Module != nil && path != pkg.Module.Path && !strings.HasPrefix(path, pkg.Module.Path+"/") {
+			continue
+		}
+		if isBuilder(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuilder reports whether the type has chaining methods
+func isBuilder(typ *types.Named) bool {
+	origin := typ.Origin()
+	methods := types.NewMethodSet(types.NewPointer(origin))
+	for i := 0; i < methods.Len(); i++ {
+		sig, ok := methods.At(i).Type().(*types.Signature)
+		if !ok || sig.Results().Len() == 0 {
+			continue
+		}
+		result := sig.Results().At(0).Type()
+		if ptr, ok := result.(*types.Pointer); ok {
+			result = ptr.Elem()
+		}
+		if named, ok := result.(*types.Named); ok && named.Origin() == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// demoteNested excludes discovered builders that are reachable as children of other discovered builders from roots.
+// It is used in recursive mode only, where such builders are rendered along with their parents
+func demoteNested(roots []*Builder) {
+	reachable := make([]map[*Builder]int, len(roots))
+	for i, root := range roots {
+		reachable[i] = map[*Builder]int{}
+		descendants(root, 1, reachable[i])
+	}
+	for i, root := range roots {
+		for j, parent := range roots {
+			depth, ok := reachable[j][root]
+			if i == j || !ok {
+				continue
+			}
+			// Builders that reference each other are kept as roots
+			if _, cycle := reachable[i][parent]; cycle {
+				continue
+			}
+			if root.Depth == 0 || depth < root.Depth {
+				root.Depth = depth
+			}
+		}
+	}
+}
+
+// descendants collects child builders with their minimal depth
+func descendants(b *Builder, depth int, found map[*Builder]int) {
+	for _, child := range b.Children {
+		if d, ok := found[child.Builder]; ok && d <= depth {
+			continue
+		}
+		found[child.Builder] = depth
+		descendants(child.Builder, depth+1, found)
+	}
+}
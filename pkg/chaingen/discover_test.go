@@ -0,0 +1,31 @@
+package chaingen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverNested(t *testing.T) {
+	source := "package m\n\n" +
+		"// chaingen:\"\"\n" +
+		"type Child struct {\n\tn int `chaingen:\"set=SetN\"`\n}\n\n" +
+		"func (c Child) Reset() Child { c.n = 0; return c }\n\n" +
+		"// chaingen:\"\"\n" +
+		"type Parent struct {\n\tC Child\n}\n"
+	for _, recursive := range []bool{false, true} {
+		dir := writeModule(t, map[string]string{"m.go": source})
+		opts := testOptions(dir)
+		opts.Recursive = recursive
+		files, err := New(opts).GenerateFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(files[filepath.Join(dir, "m.chaingen.go")])
+		for _, s := range []string{"func (c Child) SetN(n int) Child {", "p.C = p.C.Reset()"} {
+			if strings.Count(content, s) != 1 {
+				t.Fatalf("recursive=%t: expected generated code to contain %q once:\n%s", recursive, s, content)
+			}
+		}
+	}
+}
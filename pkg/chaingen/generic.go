@@ -0,0 +1,141 @@
+package chaingen
+
+import (
+	"go/types"
+)
+
+// Instance returns builder type instantiated with its own type parameters
+func (b *Builder) Instance() types.Type {
+	tps := b.Type.TypeParams()
+	if tps.Len() == 0 {
+		return b.Type
+	}
+	args := make([]types.Type, tps.Len())
+	for i := range args {
+		args[i] = tps.At(i)
+	}
+	inst, err := types.Instantiate(nil, b.Type, args, false)
+	if err != nil {
+		return b.Type
+	}
+	return inst
+}
+
+// Methods returns own and, optionally, generated methods of the child builder.
+// Type arguments of instantiated generic children are substituted into method signatures
+func (r *BuilderRef) Methods(generated bool) []Method {
+	var methods []Method
+	if r.Type == nil || r.Type.TypeArgs().Len() == 0 {
+		if generated {
+			methods = append(methods, r.Builder.GeneratedMethods...)
+		}
+		return append(methods, r.Builder.Methods...)
+	}
+	if generated {
+		smap := map[*types.TypeParam]types.Type{}
+		tps := r.Builder.Type.TypeParams()
+		for i := 0; i < tps.Len(); i++ {
+			smap[tps.At(i)] = r.Type.TypeArgs().At(i)
+		}
+		for _, m := range r.Builder.GeneratedMethods {
+			methods = append(methods, m.substitute(smap))
+		}
+	}
+	// Methods of instantiated type have receiver and signature instantiated
+	var own []Method
+	for i := 0; i < r.Type.NumMethods(); i++ {
+		fun := r.Type.Method(i)
+		sig, ok := fun.Type().(*types.Signature)
+		if !ok {
+			continue
+		}
+		own = append(own, NewMethod(r.Builder, fun, sig))
+	}
+	sortMethods(r.Builder.Package.Fset, own)
+	return append(methods, own...)
+}
+
+// substitute returns copy of the method with type parameters replaced
+func (m Method) substitute(smap map[*types.TypeParam]types.Type) Method {
+	m.Recv.Type = substitute(m.Recv.Type, smap)
+	if named, ok := substitute(m.Recv.Named, smap).(*types.Named); ok {
+		m.Recv.Named = named
+	}
+	m.Params = substituteParams(m.Params, smap)
+	m.Results = substituteParams(m.Results, smap)
+	return m
+}
+
+func substituteParams(params []MethodParam, smap map[*types.TypeParam]types.Type) []MethodParam {
+	substituted := make([]MethodParam, len(params))
+	for i, param := range params {
+		param.Type = substitute(param.Type, smap)
+		substituted[i] = param
+	}
+	return substituted
+}
+
+// substitute replaces type parameters in the type
+func substitute(typ types.Type, smap map[*types.TypeParam]types.Type) types.Type {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		if s, ok := smap[t]; ok {
+			return s
+		}
+	case *types.Pointer:
+		return types.NewPointer(substitute(t.Elem(), smap))
+	case *types.Slice:
+		return types.NewSlice(substitute(t.Elem(), smap))
+	case *types.Array:
+		return types.NewArray(substitute(t.Elem(), smap), t.Len())
+	case *types.Map:
+		return types.NewMap(substitute(t.Key(), smap), substitute(t.Elem(), smap))
+	case *types.Chan:
+		return types.NewChan(t.Dir(), substitute(t.Elem(), smap))
+	case *types.Signature:
+		return types.NewSignatureType(nil, nil, nil, substituteTuple(t.Params(), smap), substituteTuple(t.Results(), smap), t.Variadic())
+	case *types.Struct:
+		fields := make([]*types.Var, t.NumFields())
+		tags := make([]string, t.NumFields())
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			fields[i] = types.NewField(f.Pos(), f.Pkg(), f.Name(), substitute(f.Type(), smap), f.Embedded())
+			tags[i] = t.Tag(i)
+		}
+		return types.NewStruct(fields, tags)
+	case *types.Interface:
+		methods := make([]*types.Func, t.NumExplicitMethods())
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			f := t.ExplicitMethod(i)
+			methods[i] = types.NewFunc(f.Pos(), f.Pkg(), f.Name(), substitute(f.Type(), smap).(*types.Signature))
+		}
+		embeddeds := make([]types.Type, t.NumEmbeddeds())
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			embeddeds[i] = substitute(t.EmbeddedType(i), smap)
+		}
+		return types.NewInterfaceType(methods, embeddeds).Complete()
+	case *types.Named:
+		var args []types.Type
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			args = append(args, substitute(t.TypeArgs().At(i), smap))
+		}
+		if len(args) == 0 {
+			return t
+		}
+		inst, err := types.Instantiate(nil, t.Origin(), args, false)
+		if err != nil {
+			return t
+		}
+		return inst
+	}
+	return typ
+}
+
+func substituteTuple(tuple *types.Tuple, smap map[*types.TypeParam]types.Type) *types.Tuple {
+	vars := make([]*types.Var, tuple.Len())
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+		vars[i] = types.NewParam(v.Pos(), v.Pkg(), v.Name(), substitute(v.Type(), smap))
+	}
+	return types.NewTuple(vars...)
+}
@@ -0,0 +1,61 @@
+package chaingen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGenericMethodOrder(t *testing.T) {
+	files := map[string]string{
+		"parent.go": "package m\n\ntype Parent struct{ C Child[int] }\n\ntype Child[T any] struct{ v T }\n",
+	}
+	// Methods are declared in reverse alphabetical order of their names
+	names := []string{"D", "C", "B", "A"}
+	for i, name := range names {
+		files[fmt.Sprintf("child%d.go", i)] = "package m\n\nfunc (c Child[T]) " + name + "(v T) Child[T] { c.v = v; return c }\n"
+	}
+	dir := writeModule(t, files)
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	generated, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(generated[filepath.Join(dir, "parent.chaingen.go")])
+	last := -1
+	for _, name := range names {
+		pos := strings.Index(content, ") "+name+"(v int) Parent {")
+		if pos < 0 || pos < last {
+			t.Fatalf("method %s is out of order:\n%s", name, content)
+		}
+		last = pos
+	}
+}
+
+func TestGenerateGenericTypeLiterals(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+		"type Parent struct{ C Child[int] }\n\n" +
+		"type Child[T any] struct{ G Grand[T] }\n\n" +
+		"type Grand[T any] struct{ v T }\n\n" +
+		"func (g Grand[T]) Pair(p struct{ V T }) Grand[T] { g.v = p.V; return g }\n\n" +
+		"func (g Grand[T]) From(f interface{ Get() T }) Grand[T] { g.v = f.Get(); return g }\n",
+	})
+	opts := testOptions(dir)
+	opts.TypeName = "Parent"
+	opts.Recursive = true
+	generated, err := New(opts).GenerateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(generated[filepath.Join(dir, "m.chaingen.go")])
+	for _, s := range []string{
+		"func (p Parent) Pair(p0 struct{ V int }) Parent {",
+		"func (p Parent) From(f interface{ Get() int }) Parent {",
+	} {
+		if !strings.Contains(content, s) {
+			t.Fatalf("generated code doesn't contain %q:\n%s", s, content)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Setter is a chaining method generated from a plain struct field
+type Setter struct {
+	// Kind is one of ModifierSet, ModifierAppend or ModifierPut
+	Kind  string
+	Name  string
+	Field *types.Var
+}
+
+// isSetterAnnotation reports whether the field annotation declares setters
+func isSetterAnnotation(annotation string) bool {
+	modifiers, _ := parseAnnotation(nil, Annotation{Value: annotation}, setterModifiers, false)
+	return len(modifiers) > 0
+}
+
+// newSetters returns setters declared by modifiers of the field
+func newSetters(fset *token.FileSet, field *types.Var, modifiers []Modifier) ([]Setter, []error) {
+	var setters []Setter
+	var errs []error
+	for _, modifier := range modifiers {
+		setter := Setter{
+			Kind:  modifier.Kind,
+			Name:  modifier.Value,
+			Field: field,
+		}
+		if setter.Name == "" {
+			r, size := utf8.DecodeRuneInString(field.Name())
+			setter.Name = string(unicode.ToUpper(r)) + field.Name()[size:]
+		}
+		var ok bool
+		switch modifier.Kind {
+		case ModifierSet:
+			ok = true
+		case ModifierAppend:
+			_, ok = field.Type().Underlying().(*types.Slice)
+		case ModifierPut:
+			_, ok = field.Type().Underlying().(*types.Map)
+		}
+		if !ok {
+			errs = append(errs, Diagnostic{
+				Pos:     fset.Position(modifier.Pos),
+				Message: fmt.Sprintf("modifier %q can't be applied to field %s of type %s", modifier.Text, field.Name(), field.Type().String()),
+			})
+			continue
+		}
+		setters = append(setters, setter)
+	}
+	return setters, errs
+}
+
+// Method returns description of the chaining method generated for the setter
+func (s Setter) Method(b *Builder) Method {
+	recv := b.Instance()
+	if b.Pointer {
+		recv = types.NewPointer(recv)
+	}
+	m := Method{
+		Name:     s.Name,
+		Alias:    s.Name,
+		Pos:      s.Field.Pos(),
+		Exported: token.IsExported(s.Name),
+		Builder:  b,
+		Recv: MethodParam{
+			Type:  recv,
+			Named: b.Type,
+		},
+		Results: []MethodParam{
+			{
+				Type: recv,
+			},
+		},
+	}
+	r, size := utf8.DecodeRuneInString(s.Field.Name())
+	param := string(unicode.ToLower(r)) + s.Field.Name()[size:]
+	switch s.Kind {
+	case ModifierSet:
+		// Fields like Type can't be used as parameter names as is
+		if token.IsKeyword(param) {
+			param = "value"
+		}
+		m.Params = []MethodParam{{Name: param, Type: s.Field.Type()}}
+	case ModifierAppend:
+		if token.IsKeyword(param) {
+			param = "values"
+		}
+		m.Variadic = true
+		m.Params = []MethodParam{{Name: param, Type: types.NewSlice(s.Field.Type().Underlying().(*types.Slice).Elem())}}
+	case ModifierPut:
+		typ := s.Field.Type().Underlying().(*types.Map)
+		m.Params = []MethodParam{{Name: "key", Type: typ.Key()}, {Name: "value", Type: typ.Elem()}}
+	}
+	return m
+}
+
+// RenderSetter renders chaining method that sets, appends to or puts into the field.
+// Slices and maps are copied to keep builders immutable
+func (b *Builder) RenderSetter(file *File, setter Setter) Method {
+	method := setter.Method(b)
+	inputParams, callParams := b.renderParams(file, method)
+	file.L()
+	doc := method.Doc()
+	if doc != nil {
+		for _, line := range doc.List {
+			file.L(line.Text)
+		}
+	}
+	recvType := b.ReceiverType(b.Pointer)
+	file.L(`func (` + b.ReceiverName() + ` ` + recvType + `) ` + method.Alias + `(` + strings.Join(inputParams, ", ") + `) ` + recvType + " {")
+	ref := b.ReceiverName() + "." + setter.Field.Name()
+	switch setter.Kind {
+	case ModifierSet:
+		file.L("\t" + ref + " = " + callParams[0])
+	case ModifierAppend:
+		file.L("\t" + ref + " = append(" + ref + "[:len(" + ref + "):len(" + ref + ")], " + callParams[0] + ")")
+	case ModifierPut:
+		if b.Pointer {
+			file.L("\tif " + ref + " == nil {")
+			file.L("\t\t" + ref + " = make(" + file.TypeIdentifier(setter.Field.Type()) + ")")
+			file.L("\t}")
+		} else {
+			entries := file.Ident(setter.Field.Name())
+			k, v := file.Ident("k"), file.Ident("v")
+			file.L("\t" + entries + " := make(" + file.TypeIdentifier(setter.Field.Type()) + ", len(" + ref + ")+1)")
+			file.L("\tfor " + k + ", " + v + " := range " + ref + " {")
+			file.L("\t\t" + entries + "[" + k + "] = " + v)
+			file.L("\t}")
+			file.L("\t" + ref + " = " + entries)
+		}
+		file.L("\t" + ref + "[" + callParams[0] + "] = " + callParams[1])
+	}
+	file.L("\treturn " + b.ReceiverName())
+	file.L("}")
+	return method
+}
@@ -0,0 +1,103 @@
+package chaingen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetters(t *testing.T) {
+	fields := "\tlimit   int               `chaingen:\"set\"`\n" +
+		"\tType    string            `chaingen:\"set=WithType\"`\n" +
+		"\tcolumns []string          `chaingen:\"append=Select\"`\n" +
+		"\tparams  map[string]string `chaingen:\"put=Param\"`\n"
+	for _, tc := range []struct {
+		name     string
+		source   string
+		contains []string
+	}{
+		{
+			name:   "value",
+			source: "type A struct {\n" + fields + "}\n\nfunc (a A) Build() string { return a.Type }\n",
+			contains: []string{
+				"func (a A) Limit(limit int) A {\n\ta.limit = limit\n\treturn a\n}",
+				"func (a A) WithType(value string) A {\n\ta.Type = value\n\treturn a\n}",
+				"func (a A) Select(columns ...string) A {\n\ta.columns = append(a.columns[:len(a.columns):len(a.columns)], columns...)\n\treturn a\n}",
+				"func (a A) Param(key string, value string) A {\n" +
+					"\tparams := make(map[string]string, len(a.params)+1)\n" +
+					"\tfor k, v := range a.params {\n\t\tparams[k] = v\n\t}\n" +
+					"\ta.params = params\n" +
+					"\ta.params[key] = value\n" +
+					"\treturn a\n}",
+			},
+		},
+		{
+			name:   "pointer",
+			source: "type A struct {\n" + fields + "}\n\nfunc (a *A) Build() string { return a.Type }\n",
+			contains: []string{
+				"func (a *A) Limit(limit int) *A {\n\ta.limit = limit\n\treturn a\n}",
+				"func (a *A) WithType(value string) *A {\n\ta.Type = value\n\treturn a\n}",
+				"func (a *A) Select(columns ...string) *A {\n\ta.columns = append(a.columns[:len(a.columns):len(a.columns)], columns...)\n\treturn a\n}",
+				"func (a *A) Param(key string, value string) *A {\n" +
+					"\tif a.params == nil {\n\t\ta.params = make(map[string]string)\n\t}\n" +
+					"\ta.params[key] = value\n" +
+					"\treturn a\n}",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"m.go": "package m\n\n" + tc.source})
+			opts := testOptions(dir)
+			opts.TypeName = "A"
+			files, err := New(opts).GenerateFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(files[filepath.Join(dir, "m.chaingen.go")])
+			for _, s := range tc.contains {
+				if !strings.Contains(content, s) {
+					t.Fatalf("generated code doesn't contain %q:\n%s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSetterConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{
+			name:   "field",
+			source: "type A struct {\n\tLimit int `chaingen:\"set\"`\n}\n",
+			err:    "m.go:4:2: setter A.Limit of field Limit conflicts with field Limit",
+		},
+		{
+			name:   "other field",
+			source: "type A struct {\n\tSize int\n\tlimit int `chaingen:\"set=Size\"`\n}\n",
+			err:    "m.go:5:2: setter A.Size of field limit conflicts with field Size",
+		},
+		{
+			name:   "method",
+			source: "type A struct {\n\tlimit int `chaingen:\"set\"`\n}\n\nfunc (a A) Limit() int { return a.limit }\n",
+			err:    "m.go:4:2: setter A.Limit of field limit conflicts with A.Limit",
+		},
+		{
+			name:   "setter",
+			source: "type A struct {\n\tlimit int `chaingen:\"set=Set\"`\n\tsize int `chaingen:\"set=Set\"`\n}\n",
+			err:    "m.go:5:2: setter A.Set of field size is declared more than once",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"m.go": "package m\n\n" + tc.source})
+			opts := testOptions(dir)
+			opts.TypeName = "A"
+			_, err := New(opts).GenerateFiles()
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
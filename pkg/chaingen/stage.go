@@ -0,0 +1,215 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/token"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Stage is a step of the builder call order declared by stage(selector)=N modifier.
+// Optional stages are declared as stage(selector)=N? and can be skipped
+type Stage struct {
+	N        int
+	Optional bool
+}
+
+// parseStage parses stage modifier value
+func parseStage(value string) (Stage, error) {
+	stage := Stage{}
+	if strings.HasSuffix(value, "?") {
+		stage.Optional = true
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || strconv.Itoa(n) != value {
+		return stage, fmt.Errorf("invalid stage %q, expected positive number optionally followed by \"?\"", value)
+	}
+	stage.N = n
+	return stage, nil
+}
+
+// StageName returns name of the type of the builder stage
+func (b *Builder) StageName(n int) string {
+	return fmt.Sprintf("%sStage%d", b.Type.Obj().Name(), n)
+}
+
+// stagedMethods returns exported hand-written and generated methods of the builder
+// with stages declared by the builder type annotation applied
+func (b *Builder) stagedMethods() []Method {
+	var methods []Method
+	for _, method := range append(b.Methods[:len(b.Methods):len(b.Methods)], b.GeneratedMethods...) {
+		if !token.IsExported(method.Alias) {
+			continue
+		}
+		for _, modifier := range b.StageModifiers {
+			if NewGlob(modifier.Selector).Match(b.Type.Obj().Name(), method.Alias) {
+				// Stage values are validated by the annotation parser
+				method.Stage, _ = parseStage(modifier.Value)
+			}
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// RenderStages renders step builder: stage types whose methods return the next stage.
+// Stage0 is returned by Steps() method of the builder. StageN exposes methods of the stage N,
+// methods of the following optional stages, methods of the next required stage and methods without stage
+func (b *Builder) RenderStages(file *File) {
+	methods := b.stagedMethods()
+	last := 0
+	// required are declared stages that can't be skipped. Gaps in stage numbers are skipped
+	required := map[int]bool{}
+	for _, method := range methods {
+		if method.Stage.N > last {
+			last = method.Stage.N
+		}
+		if method.Stage.N > 0 && !method.Stage.Optional {
+			required[method.Stage.N] = true
+		}
+	}
+	if last == 0 {
+		return
+	}
+	// Stage types are generated for the initial stage and stages entered by chaining methods
+	entered := map[int]bool{0: true}
+	for _, method := range methods {
+		if method.Stage.N > 0 && (method.IsChaining() || method.IsFallible()) {
+			entered[method.Stage.N] = true
+		}
+	}
+
+	tps := b.Type.TypeParams()
+	var tpDecls, tpNames []string
+	for i := 0; i < tps.Len(); i++ {
+		tp := tps.At(i)
+		tpDecls = append(tpDecls, tp.Obj().Name()+" "+file.TypeIdentifier(tp.Constraint()))
+		tpNames = append(tpNames, tp.Obj().Name())
+	}
+	typeParams := func(names []string) string {
+		if len(names) == 0 {
+			return ""
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	}
+	stageType := func(n int) string {
+		return b.StageName(n) + typeParams(tpNames)
+	}
+
+	file.L()
+	file.L("// Steps returns the initial stage of step builder")
+	file.L("func (" + b.ReceiverName() + " " + b.ReceiverType(b.Pointer) + ") Steps() " + stageType(0) + " {")
+	file.L("\treturn " + stageType(0) + "{b: " + b.ReceiverName() + "}")
+	file.L("}")
+	for n := 0; n <= last; n++ {
+		if !entered[n] {
+			continue
+		}
+		file.L()
+		file.L("// " + b.StageName(n) + " is a stage of " + b.Type.Obj().Name() + " step builder")
+		file.L("type " + b.StageName(n) + typeParams(tpDecls) + " struct {")
+		file.L("\tb " + b.ReceiverType(b.Pointer))
+		file.L("}")
+		for _, method := range methods {
+			if !stageAvailable(n, method.Stage.N, last, required) {
+				continue
+			}
+			next := n
+			if method.Stage.N > 0 {
+				next = method.Stage.N
+			}
+			b.renderStageMethod(file, method, stageType(n), stageType(next))
+		}
+	}
+}
+
+// stageAvailable reports whether method of the stage can be called at the current stage:
+// the stage is the current one or no required stage precedes it
+func stageAvailable(current int, stage int, last int, required map[int]bool) bool {
+	if stage == 0 || stage == current && current > 0 {
+		return true
+	}
+	for n := current + 1; n <= last; n++ {
+		if n == stage {
+			return true
+		}
+		if required[n] {
+			return false
+		}
+	}
+	return false
+}
+
+// renderStageMethod renders method of the stage that calls the builder method.
+// Chaining methods return the next stage holding the updated builder
+func (b *Builder) renderStageMethod(file *File, method Method, recvType string, nextType string) {
+	inputParams, callParams := b.renderParams(file, method)
+	ref := b.ReceiverName() + ".b"
+	file.L()
+	doc := method.Doc()
+	if doc != nil {
+		for _, line := range doc.List {
+			file.L(line.Text)
+		}
+	}
+	signature := b.ReceiverName() + " " + recvType + ") " + method.Alias + "(" + strings.Join(inputParams, ", ") + ") "
+	if method.IsChaining() || method.IsFallible() {
+		next := nextType + "{b: " + ref + "}"
+		errName := ""
+		if method.IsFallible() {
+			errName = file.Ident("err")
+			file.L("func (" + signature + "(" + nextType + ", error) {")
+		} else {
+			file.L("func (" + signature + nextType + " {")
+		}
+		assign := b.renderChainCall(file, method, ref, b.Pointer, strings.Join(callParams, ", "), errName, func() {
+			file.L("\t\treturn " + next + ", " + errName)
+		})
+		if assign != "" {
+			file.L("\t" + assign)
+		}
+		if method.IsFallible() {
+			file.L("\treturn " + next + ", nil")
+		} else {
+			file.L("\treturn " + next)
+		}
+		file.L("}")
+		return
+	}
+	var results []string
+	for _, param := range method.Results {
+		results = append(results, file.TypeIdentifier(param.Type))
+	}
+	resultsStr := strings.Join(results, ", ")
+	if len(results) > 1 {
+		resultsStr = "(" + resultsStr + ")"
+	}
+	call := ref + "." + method.Alias + "(" + strings.Join(callParams, ", ") + ")"
+	file.L("func (" + signature + resultsStr + " {")
+	if len(results) > 0 {
+		file.L("\treturn " + call)
+	} else {
+		file.L("\t" + call)
+	}
+	file.L("}")
+}
+
+// checkStages warns about stage annotations that don't match builder methods
+func (b *Builder) checkStages() {
+	methods := b.stagedMethods()
+	for _, modifier := range b.StageModifiers {
+		glob := NewGlob(modifier.Selector)
+		matched := false
+		for _, method := range methods {
+			matched = matched || glob.Match(b.Type.Obj().Name(), method.Alias)
+		}
+		if !matched {
+			log.Printf("warning: %s", Diagnostic{
+				Pos:     b.Package.Fset.Position(modifier.Pos),
+				Message: fmt.Sprintf("modifier %q matched no methods", modifier.Text),
+			}.Error())
+		}
+	}
+}
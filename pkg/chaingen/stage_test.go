@@ -0,0 +1,88 @@
+package chaingen
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRenderStages(t *testing.T) {
+	methods := "func (a A) One() A { return a }\n\n" +
+		"func (a A) Two() A { return a }\n\n" +
+		"func (a A) Three() (A, error) { return a, nil }\n\n" +
+		"func (a A) Done() string { return \"\" }\n"
+	for _, tc := range []struct {
+		name       string
+		annotation string
+		// stages are methods exposed by every stage type
+		stages map[string][]string
+	}{
+		{
+			name:       "required",
+			annotation: "stage(One)=1,stage(Two)=2,stage(Three)=3,stage(Done)=4",
+			stages: map[string][]string{
+				"AStage0": {"One"},
+				"AStage1": {"One", "Two"},
+				"AStage2": {"Three", "Two"},
+				"AStage3": {"Done", "Three"},
+			},
+		},
+		{
+			name:       "optional",
+			annotation: "stage(One)=1,stage(Two)=2?,stage(Three)=3?,stage(Done)=4",
+			stages: map[string][]string{
+				"AStage0": {"One"},
+				"AStage1": {"Done", "One", "Three", "Two"},
+				"AStage2": {"Done", "Three", "Two"},
+				"AStage3": {"Done", "Three"},
+			},
+		},
+		{
+			name:       "gap",
+			annotation: "stage(One)=1,stage(Three)=3,stage(Done)=4",
+			stages: map[string][]string{
+				"AStage0": {"One", "Two"},
+				"AStage1": {"One", "Three", "Two"},
+				"AStage3": {"Done", "Three", "Two"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{"m.go": "package m\n\n" +
+				"// chaingen:\"" + tc.annotation + "\"\n" +
+				"type A struct{}\n\n" + methods,
+			})
+			opts := testOptions(dir)
+			opts.TypeName = "A"
+			files, err := New(opts).GenerateFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(files[filepath.Join(dir, "m.chaingen.go")])
+			stages := map[string][]string{}
+			for _, match := range regexp.MustCompile(`func \(a (AStage\d)\) (\w+)\(`).FindAllStringSubmatch(content, -1) {
+				stages[match[1]] = append(stages[match[1]], match[2])
+			}
+			for _, methods := range stages {
+				sort.Strings(methods)
+			}
+			for stage, expected := range tc.stages {
+				if strings.Join(stages[stage], ",") != strings.Join(expected, ",") {
+					t.Fatalf("expected %s methods %v, got %v:\n%s", stage, expected, stages[stage], content)
+				}
+			}
+			if len(stages) != len(tc.stages) {
+				t.Fatalf("expected stages %v, got %v:\n%s", tc.stages, stages, content)
+			}
+			if tc.name == "required" && !strings.Contains(content, "func (a AStage2) Three() (AStage3, error) {\n"+
+				"\tchild, err := a.b.Three()\n"+
+				"\tif err != nil {\n\t\treturn AStage3{b: a.b}, err\n\t}\n"+
+				"\ta.b = child\n"+
+				"\treturn AStage3{b: a.b}, nil\n}") {
+				t.Fatalf("unexpected fallible stage method:\n%s", content)
+			}
+		})
+	}
+}
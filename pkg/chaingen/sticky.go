@@ -0,0 +1,146 @@
+package chaingen
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Sticky error modes
+const (
+	// StickyFirst keeps the first error and short-circuits subsequent chaining methods
+	StickyFirst = "first"
+	// StickyJoin joins errors of all chaining methods using errors.Join
+	StickyJoin = "join"
+)
+
+var stickyModes = []string{StickyFirst, StickyJoin}
+
+// StickyError is a parent builder field annotated with errors modifier.
+// Fallible chaining methods record their errors into this field instead of returning them,
+// generated finalizers return the recorded error
+type StickyError struct {
+	Field string
+	Mode  string
+}
+
+// stickyError returns sticky error field declared by annotation of the error field
+func stickyError(field string, modifiers []Modifier) *StickyError {
+	for _, modifier := range modifiers {
+		if modifier.Kind != ModifierErrors {
+			continue
+		}
+		mode := modifier.Selector
+		if mode == "" {
+			mode = StickyFirst
+		}
+		return &StickyError{
+			Field: field,
+			Mode:  mode,
+		}
+	}
+	return nil
+}
+
+// checkStickyMode returns error if the sticky error mode is not supported by Go version declared by the module
+func checkStickyMode(pkg *packages.Package, modifiers []Modifier) error {
+	for _, modifier := range modifiers {
+		if modifier.Kind != ModifierErrors || modifier.Selector != StickyJoin || pkg.Module == nil || pkg.Module.GoVersion == "" {
+			continue
+		}
+		if goVersionBefore(pkg.Module.GoVersion, 1, 20) {
+			return Diagnostic{
+				Pos:     pkg.Fset.Position(modifier.Pos),
+				Message: fmt.Sprintf("modifier %q requires errors.Join, which is available since Go 1.20, but module %s declares go %s", modifier.Text, pkg.Module.Path, pkg.Module.GoVersion),
+			}
+		}
+	}
+	return nil
+}
+
+// goVersionBefore reports whether go directive of the module declares version older than major.minor.
+// Versions like 1.19, 1.21.0 and 1.21rc1 are supported
+func goVersionBefore(v string, major int, minor int) bool {
+	parts := strings.SplitN(v, ".", 3)
+	number := func(i int) int {
+		if i >= len(parts) {
+			return 0
+		}
+		digits := parts[i]
+		for j, r := range digits {
+			if r < '0' || r > '9' {
+				digits = digits[:j]
+				break
+			}
+		}
+		n, _ := strconv.Atoi(digits)
+		return n
+	}
+	if number(0) != major {
+		return number(0) < major
+	}
+	return number(1) < minor
+}
+
+// IsFallible reports whether generated method returns an error along with the builder
+func (b *Builder) IsFallible(method Method) bool {
+	return method.IsFallible() && b.StickyError == nil
+}
+
+// renderStickyGuard renders early return of the recorded error
+func (b *Builder) renderStickyGuard(file *File, results ...string) {
+	if b.StickyError == nil || b.StickyError.Mode == StickyJoin && results == nil {
+		return
+	}
+	ref := b.ReceiverName() + "." + b.StickyError.Field
+	file.L("\tif " + ref + " != nil {")
+	if results == nil {
+		file.L("\t\treturn " + b.ReceiverName())
+	} else {
+		file.L("\t\treturn " + strings.Join(append(results, ref), ", "))
+	}
+	file.L("\t}")
+}
+
+// renderStickyRecord renders recording of the error returned by fallible chaining method
+func (b *Builder) renderStickyRecord(file *File, errName string) {
+	ref := b.ReceiverName() + "." + b.StickyError.Field
+	if b.StickyError.Mode == StickyJoin {
+		file.L("\t\t" + ref + " = " + file.PackageIdentifier(types.NewPackage("errors", "errors")) + ".Join(" + ref + ", " + errName + ")")
+		return
+	}
+	file.L("\t\t" + ref + " = " + errName)
+	file.L("\t\treturn " + b.ReceiverName())
+}
+
+// endsWithError reports whether the last result is an error
+func endsWithError(results []MethodParam) bool {
+	return len(results) > 0 && types.Identical(results[len(results)-1].Type, errorType)
+}
+
+// zeroValue returns zero value expression of the type
+func zeroValue(file *File, typ types.Type) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "false"
+		case t.Info()&types.IsString != 0:
+			return `""`
+		case t.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		return "nil"
+	case *types.Interface:
+		if _, ok := typ.(*types.TypeParam); ok {
+			return fmt.Sprintf("*new(%s)", file.TypeIdentifier(typ))
+		}
+		return "nil"
+	}
+	return file.TypeIdentifier(typ) + "{}"
+}
@@ -0,0 +1,60 @@
+package chaingen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStickyJoinGoVersion(t *testing.T) {
+	source := "package m\n\n" +
+		"type Child struct{}\n\n" +
+		"func (c Child) Set() (Child, error) { return c, nil }\n\n" +
+		"type Parent struct {\n" +
+		"\tC   Child\n" +
+		"\terr error `chaingen:\"errors(join)\"`\n" +
+		"}\n"
+	for _, tc := range []struct {
+		goVersion string
+		err       string
+	}{
+		{goVersion: "1.19", err: `modifier "errors(join)" requires errors.Join`},
+		{goVersion: "1.20"},
+	} {
+		t.Run(tc.goVersion, func(t *testing.T) {
+			dir := writeModule(t, map[string]string{
+				"go.mod": "module example.com/m\n\ngo " + tc.goVersion + "\n",
+				"m.go":   source,
+			})
+			opts := testOptions(dir)
+			opts.TypeName = "Parent"
+			files, err := New(opts).GenerateFiles()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(files[dir+"/m.chaingen.go"]), "errors.Join(p.err, err)") {
+				t.Fatalf("errors are not joined:\n%s", files[dir+"/m.chaingen.go"])
+			}
+		})
+	}
+}
+
+func TestGoVersionBefore(t *testing.T) {
+	for v, before := range map[string]bool{
+		"1.19":    true,
+		"1.19.13": true,
+		"1.20":    false,
+		"1.20rc1": false,
+		"1.22.0":  false,
+		"2.0":     false,
+	} {
+		if goVersionBefore(v, 1, 20) != before {
+			t.Fatalf("%s: expected before=%t", v, before)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package chaingen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileState is used to detect changes of source files
+type fileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Watch generates code and regenerates it whenever Go source files of builder packages or packages of their children
+// change. Source directories are polled every interval. Generation starts once files stop changing for an interval.
+// Generation errors are logged and watching continues until the context is done.
+// Every generation loads and type checks the source code again: go/packages can't reload changed packages only
+func (c Chaingen) Watch(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid watch interval %s", interval)
+	}
+	dirs, snapshot := c.watchGenerate(nil)
+	w := watcher{snapshot: snapshot}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if !w.poll(c.snapshot(dirs)) {
+			continue
+		}
+		log.Printf("source code changed, regenerating")
+		dirs, w.snapshot = c.watchGenerate(dirs)
+	}
+}
+
+// watcher debounces changes of source files
+type watcher struct {
+	snapshot map[string]fileState
+	// pending is set if files changed since the last generation
+	pending bool
+}
+
+// poll records current states of source files and reports whether code should be regenerated:
+// files changed since the last generation and stayed unchanged since the previous poll
+func (w *watcher) poll(current map[string]fileState) bool {
+	if !sameSnapshot(w.snapshot, current) {
+		w.snapshot = current
+		w.pending = true
+		return false
+	}
+	if !w.pending {
+		return false
+	}
+	w.pending = false
+	return true
+}
+
+// watchGenerate generates code and returns directories to watch along with states of their source files.
+// States of previously watched directories are taken before the generation, so files changed during it
+// trigger the next one
+func (c Chaingen) watchGenerate(dirs map[string]bool) (map[string]bool, map[string]fileState) {
+	watched := dirs
+	if len(watched) == 0 {
+		watched = map[string]bool{c.opts.Src: true}
+	}
+	before := c.snapshot(watched)
+	found := c.watchRun(dirs)
+	return found, mergeSnapshots(watched, before, found, c.snapshot(found))
+}
+
+// mergeSnapshots returns states of source files of the directories. States of the previously watched directories
+// are taken from the previous snapshot
+func mergeSnapshots(previousDirs map[string]bool, previous map[string]fileState, dirs map[string]bool, current map[string]fileState) map[string]fileState {
+	states := map[string]fileState{}
+	for path, state := range previous {
+		if dirs[filepath.Dir(path)] {
+			states[path] = state
+		}
+	}
+	for path, state := range current {
+		if !previousDirs[filepath.Dir(path)] {
+			states[path] = state
+		}
+	}
+	return states
+}
+
+// watchRun generates code and returns directories to watch. Previously watched directories are kept
+// if source code could not be loaded
+func (c Chaingen) watchRun(dirs map[string]bool) map[string]bool {
+	start := time.Now()
+	found, err := c.run()
+	if err != nil {
+		log.Printf("error: %s", err.Error())
+	} else {
+		log.Printf("generated in %s", time.Since(start).Round(time.Millisecond))
+	}
+	if len(found) > 0 {
+		return found
+	}
+	if len(dirs) > 0 {
+		return dirs
+	}
+	return map[string]bool{c.opts.Src: true}
+}
+
+// snapshot returns states of Go source files in the directories
+func (c Chaingen) snapshot(dirs map[string]bool) map[string]fileState {
+	states := map[string]fileState{}
+	for _, path := range c.sourceFiles(dirs) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		states[path] = fileState{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		}
+	}
+	return states
+}
+
+// sourceFiles returns sorted Go source files of the directories. Generated and test files are skipped
+func (c Chaingen) sourceFiles(dirs map[string]bool) []string {
+	var files []string
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, c.opts.FileSuffix) || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func sameSnapshot(left, right map[string]fileState) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for path, state := range left {
+		if other, ok := right[path]; !ok || !other.ModTime.Equal(state.ModTime) || other.Size != state.Size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package chaingen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	state := func(size int64) map[string]fileState {
+		return map[string]fileState{"a.go": {Size: size}}
+	}
+	w := watcher{snapshot: state(1)}
+	for i, step := range []struct {
+		current    map[string]fileState
+		regenerate bool
+	}{
+		// Nothing changed
+		{current: state(1)},
+		// Changed file is regenerated once it stays unchanged for a poll
+		{current: state(2)},
+		{current: state(2), regenerate: true},
+		{current: state(2)},
+		// Regeneration is postponed while files keep changing
+		{current: state(3)},
+		{current: state(4)},
+		{current: state(4), regenerate: true},
+		// Added and removed files are changes too
+		{current: map[string]fileState{"a.go": {Size: 4}, "b.go": {Size: 1}}},
+		{current: map[string]fileState{"b.go": {Size: 1}}},
+		{current: map[string]fileState{"b.go": {Size: 1}}, regenerate: true},
+	} {
+		if regenerate := w.poll(step.current); regenerate != step.regenerate {
+			t.Fatalf("step %d: expected regenerate=%t, got %t", i, step.regenerate, regenerate)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"m.go":          "package m\n",
+		"m.chaingen.go": "package m\n",
+		"m_test.go":     "package m\n",
+		"sub/s.go":      "package sub\n",
+	})
+	c := New(testOptions(dir))
+	dirs := map[string]bool{dir: true}
+	snapshot := c.snapshot(dirs)
+	var files []string
+	for path := range snapshot {
+		files = append(files, path)
+	}
+	if expected := []string{filepath.Join(dir, "m.go")}; !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+	for _, file := range []struct {
+		name    string
+		changed bool
+	}{
+		{name: "m.chaingen.go"},
+		{name: "m_test.go"},
+		{name: "m.go", changed: true},
+	} {
+		err := os.WriteFile(filepath.Join(dir, file.name), []byte("package m\n\n// changed\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sameSnapshot(snapshot, c.snapshot(dirs)) == file.changed {
+			t.Fatalf("%s: expected changed=%t", file.name, file.changed)
+		}
+	}
+}
+
+func TestMergeSnapshots(t *testing.T) {
+	previousDirs := map[string]bool{"a": true, "b": true}
+	previous := map[string]fileState{
+		"a/a.go": {Size: 1},
+		"a/x.go": {Size: 1},
+		"b/b.go": {Size: 1},
+	}
+	dirs := map[string]bool{"a": true, "c": true}
+	// a/a.go is changed, a/x.go is removed and a/y.go is added during the generation
+	current := map[string]fileState{
+		"a/a.go": {Size: 2},
+		"a/y.go": {Size: 1},
+		"c/c.go": {Size: 1},
+	}
+	expected := map[string]fileState{
+		"a/a.go": {Size: 1},
+		"a/x.go": {Size: 1},
+		"c/c.go": {Size: 1},
+	}
+	if states := mergeSnapshots(previousDirs, previous, dirs, current); !reflect.DeepEqual(states, expected) {
+		t.Fatalf("expected states %v, got %v", expected, states)
+	}
+	w := watcher{snapshot: expected}
+	w.poll(current)
+	if !w.poll(current) {
+		t.Fatal("expected files changed during the generation to be regenerated")
+	}
+}
+
+func TestWatchRunDirs(t *testing.T) {
+	// Source code can't be loaded from the missing directory
+	src := filepath.Join(t.TempDir(), "missing")
+	c := New(testOptions(src))
+	if dirs := c.watchRun(nil); !reflect.DeepEqual(dirs, map[string]bool{src: true}) {
+		t.Fatalf("expected source directory to be watched, got %v", dirs)
+	}
+	previous := map[string]bool{filepath.Join(src, "sub"): true}
+	if dirs := c.watchRun(previous); !reflect.DeepEqual(dirs, previous) {
+		t.Fatalf("expected previously watched directories, got %v", dirs)
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	err := New(testOptions(t.TempDir())).Watch(context.Background(), 0)
+	if err == nil {
+		t.Fatal("expected error for zero interval")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = New(testOptions(t.TempDir())).Watch(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+}